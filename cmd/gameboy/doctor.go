@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/brunocroh/gameboy/gameboy"
+	"github.com/brunocroh/gameboy/gameboy/cpu"
+)
+
+// if the CPU doesn't execute an instruction for this many updates (halted
+// forever) the run is reported as stalled
+const doctorStallUpdates = gameboy.CyclesPerFrame * 60
+
+// doctorComparer receives the Gameboy-Doctor trace of the emulator and
+// compares it line by line with the expected log.
+type doctorComparer struct {
+	expected *bufio.Scanner
+	context  int
+
+	partial []byte
+	history []string
+	line    int
+
+	done     bool
+	diverged bool
+	expect   string
+	got      string
+}
+
+func (m *doctorComparer) Write(p []byte) (int, error) {
+	m.partial = append(m.partial, p...)
+
+	for !m.done && !m.diverged {
+		i := bytes.IndexByte(m.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		got := string(m.partial[:i])
+		m.partial = m.partial[i+1:]
+		m.compare(got)
+	}
+
+	return len(p), nil
+}
+
+func (m *doctorComparer) compare(got string) {
+	if !m.expected.Scan() {
+		m.done = true
+		return
+	}
+
+	m.line++
+	expect := strings.TrimSpace(m.expected.Text())
+
+	if expect != got {
+		m.diverged = true
+		m.expect = expect
+		m.got = got
+		return
+	}
+
+	m.history = append(m.history, got)
+	if len(m.history) > m.context {
+		m.history = m.history[1:]
+	}
+}
+
+func (m *doctorComparer) report() {
+	fmt.Printf("diverged at line %d\n\n", m.line)
+
+	if len(m.history) > 0 {
+		fmt.Printf("previous %d instructions:\n", len(m.history))
+		for _, line := range m.history {
+			fmt.Printf("  %s  %s\n", line, disassembleLine(line))
+		}
+		fmt.Println()
+	}
+
+	expectFields := parseDoctorLine(m.expect)
+	gotFields := parseDoctorLine(m.got)
+
+	var marks strings.Builder
+	var diffs []string
+	for _, field := range strings.Fields(m.got) {
+		name, value, _ := strings.Cut(field, ":")
+		mark := " "
+		if expectFields[name] != value {
+			mark = "^"
+			diffs = append(diffs, fmt.Sprintf("%s expected %s got %s", name, expectFields[name], value))
+		}
+		marks.WriteString(strings.Repeat(" ", len(name)+1))
+		marks.WriteString(strings.Repeat(mark, len(value)))
+		marks.WriteString(" ")
+	}
+
+	fmt.Printf("expected: %s\n", m.expect)
+	fmt.Printf("got:      %s\n", m.got)
+	fmt.Printf("          %s\n\n", strings.TrimRight(marks.String(), " "))
+
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+
+	fmt.Printf("\nat PC %s: %s\n", gotFields["PC"], disassembleLine(m.got))
+}
+
+// parseDoctorLine splits "A:01 F:B0 ..." into its fields.
+func parseDoctorLine(line string) map[string]string {
+	fields := map[string]string{}
+	for _, field := range strings.Fields(line) {
+		name, value, _ := strings.Cut(field, ":")
+		fields[name] = value
+	}
+	return fields
+}
+
+// disassembleLine decodes the instruction from the PC and PCMEM fields.
+func disassembleLine(line string) string {
+	fields := parseDoctorLine(line)
+
+	pc, err := strconv.ParseUint(fields["PC"], 16, 16)
+	if err != nil {
+		return "?"
+	}
+
+	var pcmem [4]byte
+	for i, b := range strings.Split(fields["PCMEM"], ",") {
+		v, err := strconv.ParseUint(b, 16, 8)
+		if err != nil || i >= len(pcmem) {
+			return "?"
+		}
+		pcmem[i] = byte(v)
+	}
+
+	text, _ := cpu.Disassemble(func(address uint16) byte {
+		return pcmem[(address-uint16(pc))&0x03]
+	}, uint16(pc))
+
+	return text
+}
+
+func doctor(args []string) {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	romPtr := flags.String("rom", "", "rom to execute")
+	expectedPtr := flags.String("expected", "", "Gameboy-Doctor reference log")
+	contextPtr := flags.Int("context", 10, "instructions to show before the divergence")
+
+	flags.Parse(args)
+
+	file, err := os.Open(*expectedPtr)
+	if err != nil {
+		fmt.Println("fail to open expected log", err)
+		os.Exit(2)
+	}
+	defer file.Close()
+
+	comparer := &doctorComparer{
+		expected: bufio.NewScanner(file),
+		context:  *contextPtr,
+	}
+
+	// the reference logs start at the cartridge entry point
+	gb := gameboy.New()
+	gb.SetSkipBoot(true)
+	gb.SetLYStub(true)
+	gb.Init(*romPtr)
+	gb.SetLockupPolicy(gameboy.LockupStop)
+	gb.SetTrace(comparer, cpu.TraceDoctor)
+
+	idle := 0
+	for !comparer.done && !comparer.diverged {
+		line := comparer.line
+
+		if err := gb.Update(); err != nil {
+			fmt.Printf("stopped after %d matching lines: %s\n", comparer.line, err)
+			os.Exit(1)
+		}
+
+		if comparer.line != line {
+			idle = 0
+		} else if idle++; idle > doctorStallUpdates {
+			fmt.Printf("stalled after %d matching lines, no instruction is being executed\n", comparer.line)
+			os.Exit(1)
+		}
+	}
+
+	if comparer.diverged {
+		comparer.report()
+		os.Exit(1)
+	}
+
+	fmt.Printf("all %d lines match\n", comparer.line)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/brunocroh/gameboy/gameboy"
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+)
+
+func dump(args []string) {
+	flags := flag.NewFlagSet("dump", flag.ExitOnError)
+	romPtr := flags.String("rom", "", "rom to execute")
+	framesPtr := flags.Int("after-frames", 0, "frames to run before dumping")
+	regionPtr := flags.String("region", "wram", "region to dump: rom, vram, sram, wram, oam, io or hram")
+	bankPtr := flags.Int("bank", 0, "rom or sram bank")
+	outPtr := flags.String("o", "", "write the raw bytes to a file instead of printing them in hex")
+	skipBootPtr := flags.Bool("skip-boot", false, "start the cartridge right away with the post boot state")
+
+	flags.Parse(args)
+
+	region, err := mmu.ParseRegion(*regionPtr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	gb := gameboy.New()
+	gb.SetSkipBoot(*skipBootPtr)
+	gb.Init(*romPtr)
+
+	for i := 0; i < *framesPtr; i++ {
+		if err := gb.RunFrame(); err != nil {
+			fmt.Printf("stopped at frame %d: %s\n", i, err)
+			os.Exit(1)
+		}
+	}
+
+	data, err := gb.Dump(region, *bankPtr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	if *outPtr == "" {
+		fmt.Print(mmu.HexDump(data, region.Base(*bankPtr)))
+		return
+	}
+
+	if err := os.WriteFile(*outPtr, data, 0644); err != nil {
+		fmt.Println("fail to write dump", err)
+		os.Exit(1)
+	}
+}
@@ -2,38 +2,193 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/brunocroh/gameboy/gameboy"
+	"github.com/brunocroh/gameboy/gameboy/cpu"
+	"github.com/brunocroh/gameboy/gameboy/mmu"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "doctor":
+			doctor(os.Args[2:])
+			return
+		case "dump":
+			dump(os.Args[2:])
+			return
+		case "profile":
+			profile(os.Args[2:])
+			return
+		}
+	}
 
 	romPtr := flag.String("rom", "", "rom to execute")
 	singleStepPtr := flag.Bool("single-step", false, "enable single step execution")
+	speedPtr := flag.Float64("speed", 1, "speed multiplier, from 0.25 up, 0 runs unlimited")
+	turboPtr := flag.Bool("turbo", false, "start with turbo (unlimited speed) enabled, typing t and enter toggles it")
+	lockupPtr := flag.String("lockup", "hang", "what to do on illegal opcodes: hang like the hardware or stop")
+	tracePtr := flag.String("trace", "", "trace the cpu state before each instruction: doctor, bgb or binary")
+	traceOutPtr := flag.String("trace-out", "", "file to write the trace to, stdout by default")
+	bootROMPtr := flag.String("bootrom", "", "boot rom image to run instead of the embedded DMG one")
+	skipBootPtr := flag.Bool("skip-boot", false, "start the cartridge right away with the post boot state")
+	modelPtr := flag.String("model", "dmg", "console model: dmg, mgb, sgb or cgb")
+	var watches watchFlags
+	flag.Var(&watches, "watch", "watch memory, START[-END][:rwcp] in hex: r, w, c watch reads, writes, changes and p pauses")
+	debugMessagesPtr := flag.Bool("debug-messages", false, "print the LD D,D debug messages of the rom")
+	logBlockedPtr := flag.Bool("log-blocked", false, "print the VRAM and OAM accesses blocked by the PPU mode with their PC")
 
 	flag.Parse()
 
+	model, err := gameboy.ParseModel(*modelPtr)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
 	gb := gameboy.New()
+	gb.SetModel(model)
+	gb.SetSkipBoot(*skipBootPtr)
+
+	if *bootROMPtr != "" {
+		data, err := os.ReadFile(*bootROMPtr)
+		if err == nil {
+			err = gb.SetBootROM(data)
+		}
+		if err != nil {
+			fmt.Println("fail to load boot rom", err)
+			os.Exit(2)
+		}
+	}
+
 	gb.Init(*romPtr)
 
-	reader := bufio.NewReader(os.Stdin)
+	var trace *bufio.Writer
+	if *tracePtr != "" {
+		format, err := cpu.ParseTraceFormat(*tracePtr)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+
+		out := os.Stdout
+		if *traceOutPtr != "" {
+			out, err = os.Create(*traceOutPtr)
+			if err != nil {
+				fmt.Println("fail to create trace file", err)
+				os.Exit(1)
+			}
+		}
+
+		trace = bufio.NewWriter(out)
+		gb.SetTrace(trace, format)
+	}
+
+	if *debugMessagesPtr {
+		gb.OnDebugMessage(func(message string) {
+			fmt.Fprintln(os.Stderr, message)
+		})
+	}
+
+	if *logBlockedPtr {
+		gb.OnBlockedAccess(func(e mmu.BlockedAccess) {
+			fmt.Fprintln(os.Stderr, e)
+		})
+	}
+
+	switch *lockupPtr {
+	case "hang":
+		gb.OnLockup(func(err *cpu.LockupError) {
+			fmt.Fprintln(os.Stderr, err)
+		})
+	case "stop":
+		gb.SetLockupPolicy(gameboy.LockupStop)
+	default:
+		fmt.Println("invalid lockup policy", *lockupPtr)
+		os.Exit(2)
+	}
+
+	for _, spec := range watches {
+		gb.Watch(spec.start, spec.end, spec.kind, spec.pause, func(e mmu.WatchEvent) {
+			fmt.Fprintln(os.Stderr, e.Error())
+		})
+	}
+
+	singleStep := *singleStepPtr
+	commands := readLines(os.Stdin)
+	pacer := gameboy.NewPacer(*speedPtr)
+	pacer.SetTurbo(*turboPtr)
 
 	for {
-		if *singleStepPtr {
-			_, err := reader.ReadString('\n')
+		var err error
 
-			if err != nil {
-				fmt.Println("fail to read", err)
+		if singleStep {
+			if _, ok := <-commands; !ok {
+				fmt.Println("fail to read", io.EOF)
 				return
 			}
 
+			err = gb.Update()
+		} else {
+			err = gb.RunFrame()
+		}
+		flushTrace(trace)
+
+		var event *mmu.WatchEvent
+		if errors.As(err, &event) {
+			// the watchpoint was already printed by its callback
+			fmt.Println("paused, press enter to step")
+			singleStep = true
+			continue
+		}
+
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
 
-		// time.Sleep(100 * time.Millisecond)
+		if singleStep {
+			continue
+		}
+
+		select {
+		case command, ok := <-commands:
+			if !ok {
+				// stdin is closed, stop polling it
+				commands = nil
+			} else if strings.TrimSpace(command) == "t" {
+				pacer.ToggleTurbo()
+				fmt.Println("turbo", pacer.Turbo())
+			}
+		default:
+		}
+
+		pacer.Wait()
+	}
+}
+
+// readLines sends the lines typed on r, they step the emulation in single
+// step mode and run commands otherwise. The channel is closed at the end of r.
+func readLines(r io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
 
-		gb.Update()
+func flushTrace(trace *bufio.Writer) {
+	if trace != nil {
+		trace.Flush()
 	}
 }
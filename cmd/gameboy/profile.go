@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/brunocroh/gameboy/gameboy"
+)
+
+func profile(args []string) {
+	flags := flag.NewFlagSet("profile", flag.ExitOnError)
+	romPtr := flags.String("rom", "", "rom to execute")
+	framesPtr := flags.Int("frames", 600, "frames to run")
+	topPtr := flags.Int("top", 10, "amount of code and data ranges to report")
+	heatmapPtr := flags.String("heatmap", "", "write a 256x256 png heatmap of the address space to a file")
+	skipBootPtr := flags.Bool("skip-boot", false, "start the cartridge right away with the post boot state")
+
+	flags.Parse(args)
+
+	gb := gameboy.New()
+	gb.SetSkipBoot(*skipBootPtr)
+	gb.SetProfiling(true)
+	gb.Init(*romPtr)
+
+	for i := 0; i < *framesPtr; i++ {
+		if err := gb.RunFrame(); err != nil {
+			fmt.Printf("stopped at frame %d: %s\n", i, err)
+			break
+		}
+	}
+
+	profiler := gb.Profiler()
+	if err := profiler.WriteReport(os.Stdout, *topPtr); err != nil {
+		fmt.Println("fail to write report", err)
+		os.Exit(1)
+	}
+
+	if *heatmapPtr == "" {
+		return
+	}
+
+	f, err := os.Create(*heatmapPtr)
+	if err == nil {
+		err = profiler.WriteHeatmap(f)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Println("fail to write heatmap", err)
+		os.Exit(1)
+	}
+}
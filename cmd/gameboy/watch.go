@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+)
+
+type watchSpec struct {
+	start uint16
+	end   uint16
+	kind  mmu.WatchKind
+	pause bool
+}
+
+// watchFlags collects the -watch flags, START[-END][:rwcp] with hexadecimal
+// addresses. r, w and c watch reads, writes and value changes (w by default),
+// p pauses into single step mode.
+type watchFlags []watchSpec
+
+func (m *watchFlags) String() string {
+	return fmt.Sprint(*m)
+}
+
+func (m *watchFlags) Set(value string) error {
+	spec, err := parseWatch(value)
+	if err != nil {
+		return err
+	}
+
+	*m = append(*m, spec)
+	return nil
+}
+
+func parseWatch(value string) (watchSpec, error) {
+	var spec watchSpec
+
+	rangeText, flags, _ := strings.Cut(value, ":")
+	startText, endText, isRange := strings.Cut(rangeText, "-")
+
+	start, err := parseAddress(startText)
+	if err != nil {
+		return spec, err
+	}
+	spec.start, spec.end = start, start
+
+	if isRange {
+		spec.end, err = parseAddress(endText)
+		if err != nil {
+			return spec, err
+		}
+		if spec.end < spec.start {
+			return spec, fmt.Errorf("watch range %s ends before it starts", rangeText)
+		}
+	}
+
+	for _, flag := range flags {
+		switch flag {
+		case 'r':
+			spec.kind |= mmu.WatchRead
+		case 'w':
+			spec.kind |= mmu.WatchWrite
+		case 'c':
+			spec.kind |= mmu.WatchChange
+		case 'p':
+			spec.pause = true
+		default:
+			return spec, fmt.Errorf("invalid watch flag %q, use r, w, c or p", flag)
+		}
+	}
+
+	if spec.kind == 0 {
+		spec.kind = mmu.WatchWrite
+	}
+
+	return spec, nil
+}
+
+func parseAddress(text string) (uint16, error) {
+	text = strings.TrimPrefix(strings.TrimPrefix(text, "0x"), "$")
+	address, err := strconv.ParseUint(text, 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address %q", text)
+	}
+	return uint16(address), nil
+}
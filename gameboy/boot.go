@@ -0,0 +1,156 @@
+package gameboy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brunocroh/gameboy/gameboy/cpu"
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+)
+
+// Model is the console being emulated, it decides the state left by the
+// boot ROM when it is skipped.
+type Model int
+
+const (
+	DMG Model = iota
+	MGB       // Game Boy Pocket
+	SGB
+	CGB
+)
+
+var modelNames = map[Model]string{
+	DMG: "dmg",
+	MGB: "mgb",
+	SGB: "sgb",
+	CGB: "cgb",
+}
+
+func (m Model) String() string {
+	return modelNames[m]
+}
+
+// ParseModel returns the model of a name (dmg, mgb, sgb or cgb).
+func ParseModel(name string) (Model, error) {
+	for model, modelName := range modelNames {
+		if strings.EqualFold(name, modelName) {
+			return model, nil
+		}
+	}
+	return DMG, fmt.Errorf("unknown model %q, use dmg, mgb, sgb or cgb", name)
+}
+
+type ioValue struct {
+	address uint16
+	value   byte
+}
+
+// I/O registers after the DMG boot ROM, from the Pan Docs power up sequence.
+// DIV is set apart through the system counter, writing it would reset it.
+var postBootIO = []ioValue{
+	{mmu.P1, 0xCF},
+	{mmu.SB, 0x00},
+	{mmu.SC, 0x7E},
+	{mmu.TIMA, 0x00},
+	{mmu.TMA, 0x00},
+	{mmu.TAC, 0xF8},
+	{0xFF0F, 0xE1}, // IF
+	{0xFF10, 0x80}, // NR10
+	{0xFF11, 0xBF}, // NR11
+	{0xFF12, 0xF3}, // NR12
+	{0xFF13, 0xFF}, // NR13
+	{0xFF14, 0xBF}, // NR14
+	{0xFF16, 0x3F}, // NR21
+	{0xFF17, 0x00}, // NR22
+	{0xFF18, 0xFF}, // NR23
+	{0xFF19, 0xBF}, // NR24
+	{0xFF1A, 0x7F}, // NR30
+	{0xFF1B, 0xFF}, // NR31
+	{0xFF1C, 0x9F}, // NR32
+	{0xFF1D, 0xFF}, // NR33
+	{0xFF1E, 0xBF}, // NR34
+	{0xFF20, 0xFF}, // NR41
+	{0xFF21, 0x00}, // NR42
+	{0xFF22, 0x00}, // NR43
+	{0xFF23, 0xBF}, // NR44
+	{0xFF24, 0x77}, // NR50
+	{0xFF25, 0xF3}, // NR51
+	{0xFF26, 0xF1}, // NR52
+	{0xFF40, 0x91}, // LCDC
+	{0xFF41, 0x85}, // STAT
+	{0xFF42, 0x00}, // SCY
+	{0xFF43, 0x00}, // SCX
+	{0xFF45, 0x00}, // LYC
+	{0xFF46, 0xFF}, // DMA
+	{0xFF47, 0xFC}, // BGP
+	{0xFF4A, 0x00}, // WY
+	{0xFF4B, 0x00}, // WX
+	{0xFFFF, 0x00}, // IE
+}
+
+// registers differing from the DMG
+var postBootIOModel = map[Model][]ioValue{
+	SGB: {
+		{0xFF26, 0xF0}, // NR52
+	},
+	CGB: {
+		{mmu.SC, 0x7F},
+		{0xFF46, 0x00}, // DMA
+		{0xFF4F, 0xFE}, // VBK
+		{0xFF56, 0x3E}, // RP
+		{0xFF70, 0xF8}, // SVBK
+	},
+}
+
+// system counter after the boot ROM, it is undocumented for SGB and CGB
+var postBootCounter = map[Model]uint16{
+	DMG: 0xABCC,
+	MGB: 0xABCC,
+}
+
+// postBootState returns the CPU registers left by the boot ROM of the model.
+// On DMG and MGB the H and C flags depend on the header checksum.
+func postBootState(model Model, rom []byte) cpu.State {
+	s := cpu.State{SP: 0xFFFE, PC: 0x0100}
+
+	switch model {
+	case DMG, MGB:
+		s.AF = 0x0180
+		if model == MGB {
+			s.AF = 0xFF80
+		}
+		if len(rom) > 0x14D && rom[0x14D] != 0 {
+			s.AF |= 0x30
+		}
+		s.BC = 0x0013
+		s.DE = 0x00D8
+		s.HL = 0x014D
+	case SGB:
+		s.AF = 0x0100
+		s.BC = 0x0014
+		s.DE = 0x0000
+		s.HL = 0xC060
+	case CGB:
+		s.AF = 0x1180
+		s.BC = 0x0000
+		s.DE = 0xFF56
+		s.HL = 0x000D
+	}
+
+	return s
+}
+
+// applyPostBoot puts the system in the state the boot ROM of the model leaves
+// it. The registers are set on the memory itself, the watchpoints and the
+// profiler don't see them.
+func (m *GameBoy) applyPostBoot(rom []byte) {
+	for _, io := range postBootIO {
+		m.memory.SetRegister(io.address, io.value)
+	}
+	for _, io := range postBootIOModel[m.model] {
+		m.memory.SetRegister(io.address, io.value)
+	}
+
+	m.memory.SetSystemCounter(postBootCounter[m.model])
+	m.cpu.SetState(postBootState(m.model, rom))
+}
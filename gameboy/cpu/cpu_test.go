@@ -0,0 +1,183 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+func TestStop(t *testing.T) {
+	s := scheduler.New()
+	m := mmu.NewMemoryManagementUnitSimple(s)
+	rom := make([]byte, 0x8000)
+	copy(rom[0x0100:], []byte{
+		0x10, 0x00, // STOP
+		0x3E, 0x42, // LD A, 0x42
+		0xEA, 0x00, 0xC0, // LD (0xC000), A
+	})
+	m.Init(rom)
+	c := New(m, s)
+	c.Init()
+	c.PC = 0x0100
+
+	// select the buttons
+	m.WB(mmu.P1, 0x10)
+
+	c.Cycle()
+	if !c.Stopped() {
+		t.Fatal("STOP didn't stop the CPU")
+	}
+
+	now := s.Now()
+	c.Cycle()
+	if !c.Stopped() || s.Now() != now {
+		t.Errorf("the CPU ran %d cycles while stopped", s.Now()-now)
+	}
+
+	m.SetButton(mmu.ButtonStart, true)
+	c.Cycle()
+	c.Cycle()
+	if c.Stopped() {
+		t.Fatal("pressing Start didn't wake the CPU up")
+	}
+	if got := m.RB(0xC000); got != 0x42 {
+		t.Errorf("0xC000 = %02X after waking up, want 42", got)
+	}
+	if m.RB(0xFF0F)&0x10 == 0 {
+		t.Error("pressing Start didn't request the joypad interrupt")
+	}
+	if got := m.RB(mmu.P1); got != 0xD7 {
+		t.Errorf("P1 read %02X with Start pressed, want D7", got)
+	}
+}
+
+// newHaltTest runs code from 0x0100 on the simple MMU with the timer
+// interrupt enabled.
+func newHaltTest(code ...byte) (*CPU, *mmu.MemoryManagementUnitSimple) {
+	s := scheduler.New()
+	m := mmu.NewMemoryManagementUnitSimple(s)
+	rom := make([]byte, 0x8000)
+	copy(rom[0x0100:], code)
+	m.Init(rom)
+	c := New(m, s)
+	c.Init()
+	m.WB(mmu.IE, byte(mmu.InterruptTimer))
+	return c, m
+}
+
+func TestHaltWakeUp(t *testing.T) {
+	for _, ime := range []bool{false, true} {
+		c, m := newHaltTest(
+			0x76,       // HALT
+			0x3E, 0x42, // LD A, 0x42
+		)
+		c.SetState(State{PC: 0x0100, SP: 0xFFFE, IME: ime})
+
+		// TIMA overflows after 16 cycles
+		m.WB(mmu.TIMA, 0xFF)
+		m.WB(mmu.TAC, 0x05)
+
+		c.Cycle()
+		if !c.interrupt.Halt {
+			t.Fatalf("IME %t: HALT didn't halt", ime)
+		}
+
+		for i := 0; c.interrupt.Halt && i < 10; i++ {
+			if c.PC != 0x0101 {
+				t.Fatalf("IME %t: PC moved to %04X while halted", ime, c.PC)
+			}
+			c.Cycle()
+		}
+		if c.interrupt.Halt {
+			t.Fatalf("IME %t: the timer interrupt didn't wake the CPU up", ime)
+		}
+
+		if ime {
+			// dispatched right away, returning after HALT
+			if c.PC != 0x0050 || c.SP != 0xFFFC || m.RB(0xFFFC) != 0x01 || m.RB(0xFFFD) != 0x01 {
+				t.Errorf("IME true: PC %04X SP %04X after waking up, want the timer handler with 0101 pushed", c.PC, c.SP)
+			}
+			continue
+		}
+
+		// without IME it goes on with the next instruction, IF stays set
+		if c.register.a != 0x42 || c.PC != 0x0103 {
+			t.Errorf("IME false: A %02X PC %04X after waking up, want 42 and 0103", c.register.a, c.PC)
+		}
+		if m.RB(mmu.IF)&byte(mmu.InterruptTimer) == 0 {
+			t.Error("IME false: waking up acknowledged the interrupt")
+		}
+	}
+}
+
+// with IME=0 and an interrupt already pending HALT doesn't halt, the next
+// byte is fetched twice
+func TestHaltBug(t *testing.T) {
+	c, m := newHaltTest(
+		0x76, // HALT
+		0x3C, // INC A
+		0x00, // NOP
+	)
+	c.SetState(State{PC: 0x0100, SP: 0xFFFE})
+	m.Interrupts().Request(mmu.InterruptTimer)
+
+	c.Cycle()
+	if c.interrupt.Halt {
+		t.Fatal("HALT halted with an interrupt pending")
+	}
+
+	c.Cycle()
+	c.Cycle()
+	if c.register.a != 0x02 || c.PC != 0x0102 {
+		t.Errorf("A %02X PC %04X after the HALT bug, want INC A twice and 0102", c.register.a, c.PC)
+	}
+}
+
+// idle skips to the next scheduler event, rounded up to whole machine cycles
+func TestHaltIdle(t *testing.T) {
+	tests := []struct {
+		in        uint64
+		scheduled bool
+		want      uint32
+	}{
+		{100, true, 25},
+		{6, true, 2},
+		{8, true, 2},
+		{9, true, 3},
+		{2, true, 1},
+		{0, false, 1},
+	}
+
+	s := scheduler.New()
+	bus := &flatMMU{scheduler: s, interrupts: mmu.InterruptControllerNew()}
+	c := New(bus, s)
+
+	for _, test := range tests {
+		s.Init()
+		bus.reset()
+		c.Init()
+
+		var fired uint64
+		s.Handle(scheduler.SerialBit, func(late uint64) {
+			fired = s.Now()
+		})
+
+		bus.memory[0xC000] = 0x76 // HALT
+		c.SetState(State{PC: 0xC000, SP: 0xD000})
+		c.Cycle()
+
+		start := s.Now()
+		if test.scheduled {
+			s.Schedule(scheduler.SerialBit, test.in)
+		}
+
+		c.Cycle()
+		if c.cycles != test.want || s.Now() != start+uint64(test.want)*4 {
+			t.Errorf("event in %d: idle took %d machine cycles up to %d, want %d", test.in, c.cycles, s.Now()-start, test.want)
+		}
+		if test.scheduled && fired != start+test.in {
+			t.Errorf("event in %d: fired at %d, want %d", test.in, fired-start, test.in)
+		}
+	}
+}
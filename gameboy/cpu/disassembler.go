@@ -0,0 +1,40 @@
+package cpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Disassemble decodes the instruction at address, fetching its bytes with read.
+// It returns the instruction with its operands resolved and its length.
+func Disassemble(read func(address uint16) byte, address uint16) (string, uint8) {
+	op := Opcodes[read(address)]
+	if op.Mnemonic == "PREFIX" {
+		op = CBOpcodes[read(address+1)]
+	}
+
+	n8 := read(address + 1)
+	n16 := uint16(read(address+2))<<8 | uint16(n8)
+
+	text := op.Mnemonic
+	switch {
+	case strings.Contains(text, "n16"):
+		text = strings.Replace(text, "n16", fmt.Sprintf("$%04X", n16), 1)
+	case strings.Contains(text, "a16"):
+		text = strings.Replace(text, "a16", fmt.Sprintf("$%04X", n16), 1)
+	case strings.Contains(text, "n8"):
+		text = strings.Replace(text, "n8", fmt.Sprintf("$%02X", n8), 1)
+	case strings.Contains(text, "a8"):
+		text = strings.Replace(text, "a8", fmt.Sprintf("$FF%02X", n8), 1)
+	case strings.HasPrefix(text, "JR"):
+		// relative jumps are shown with their target address
+		target := address + uint16(op.Length) + uint16(int8(n8))
+		text = strings.Replace(text, "e8", fmt.Sprintf("$%04X", target), 1)
+	case strings.Contains(text, "+e8"):
+		text = strings.Replace(text, "+e8", fmt.Sprintf("%+d", int8(n8)), 1)
+	case strings.Contains(text, "e8"):
+		text = strings.Replace(text, "e8", fmt.Sprintf("%d", int8(n8)), 1)
+	}
+
+	return text, op.Length
+}
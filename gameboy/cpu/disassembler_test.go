@@ -0,0 +1,44 @@
+package cpu
+
+import "testing"
+
+func TestDisassemble(t *testing.T) {
+	tests := []struct {
+		code   []byte
+		text   string
+		length uint8
+	}{
+		{[]byte{0x00}, "NOP", 1},
+		{[]byte{0x01, 0x34, 0x12}, "LD BC, $1234", 3},
+		{[]byte{0x08, 0x00, 0xC0}, "LD ($C000), SP", 3},
+		{[]byte{0x3E, 0x42}, "LD A, $42", 2},
+		{[]byte{0x36, 0x99}, "LD (HL), $99", 2},
+		{[]byte{0xE0, 0x44}, "LDH ($FF44), A", 2},
+		{[]byte{0xF0, 0x0F}, "LDH A, ($FF0F)", 2},
+		{[]byte{0xE2}, "LDH (C), A", 1},
+		// relative jumps show their target, from the end of the instruction
+		{[]byte{0x20, 0xFE}, "JR NZ, $0150", 2},
+		{[]byte{0x18, 0x05}, "JR $0157", 2},
+		{[]byte{0xE8, 0xFE}, "ADD SP, -2", 2},
+		{[]byte{0xF8, 0x05}, "LD HL, SP+5", 2},
+		{[]byte{0xC4, 0x00, 0x40}, "CALL NZ, $4000", 3},
+		{[]byte{0xE9}, "JP HL", 1},
+		{[]byte{0xFF}, "RST $38", 1},
+		{[]byte{0x10, 0x00}, "STOP $00", 2},
+		{[]byte{0xCB, 0x7C}, "BIT 7, H", 2},
+		{[]byte{0xCB, 0x36}, "SWAP (HL)", 2},
+		{[]byte{0xD3}, "ILLEGAL_D3", 1},
+	}
+
+	for _, test := range tests {
+		var memory [0x10000]byte
+		copy(memory[0x0150:], test.code)
+
+		text, length := Disassemble(func(address uint16) byte {
+			return memory[address]
+		}, 0x0150)
+		if text != test.text || length != test.length {
+			t.Errorf("Disassemble(% X) = %q, %d, want %q, %d", test.code, text, length, test.text, test.length)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package cpu
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// BreakpointOpcode is LD B,B, used as a software breakpoint by mooneye's
+	// test ROMs and the debug builds of many homebrews.
+	BreakpointOpcode = 0x40
+	// DebugMessageOpcode is LD D,D, the no$gmb/BGB debug message convention.
+	DebugMessageOpcode = 0x52
+)
+
+// OpcodeHook is called right before a hooked opcode is executed, the state's
+// PC is the address of the opcode.
+type OpcodeHook func(s State)
+
+// SetOpcodeHook calls hook every time the opcode executes, nil removes it.
+// The opcode is still executed normally.
+func (m *CPU) SetOpcodeHook(opcode byte, hook OpcodeHook) {
+	m.hooks[opcode] = hook
+}
+
+// SetFetchHook calls hook with the address of every opcode right before it
+// is fetched, nil removes it.
+func (m *CPU) SetFetchHook(hook func(address uint16)) {
+	m.fetchHook = hook
+}
+
+// OnBreakpoint calls hook when LD B,B executes.
+func (m *CPU) OnBreakpoint(hook OpcodeHook) {
+	m.SetOpcodeHook(BreakpointOpcode, hook)
+}
+
+// OnDebugMessage calls fn with the messages printed with LD D,D. The message
+// follows the opcode, skipped over by a JR:
+//
+//	ld d,d
+//	jr .end
+//	dw $6464
+//	dw $0000
+//	db "message"
+//	.end:
+//
+// Register expressions like %A% or %HL% are replaced by their value.
+func (m *CPU) OnDebugMessage(fn func(message string)) {
+	if fn == nil {
+		m.SetOpcodeHook(DebugMessageOpcode, nil)
+		return
+	}
+
+	m.SetOpcodeHook(DebugMessageOpcode, func(s State) {
+		if message, ok := m.debugMessage(s); ok {
+			fn(message)
+		}
+	})
+}
+
+func (m *CPU) debugMessage(s State) (string, bool) {
+	address := s.PC + 1
+
+	if m.mmu.Peek(address) != 0x18 {
+		return "", false
+	}
+	if m.mmu.Peek(address+2) != 0x64 || m.mmu.Peek(address+3) != 0x64 ||
+		m.mmu.Peek(address+4) != 0x00 || m.mmu.Peek(address+5) != 0x00 {
+		return "", false
+	}
+
+	// the JR skips over the signature and the text
+	length := int(int8(m.mmu.Peek(address+1))) - 4
+	if length < 0 {
+		return "", false
+	}
+
+	var text strings.Builder
+	for i := 0; i < length; i++ {
+		text.WriteByte(m.mmu.Peek(address + 6 + uint16(i)))
+	}
+
+	return expandDebugMessage(text.String(), s), true
+}
+
+// expandDebugMessage replaces the %REGISTER% expressions of a message.
+func expandDebugMessage(message string, s State) string {
+	values := map[string]string{
+		"A":  fmt.Sprintf("%02X", s.AF>>8),
+		"F":  fmt.Sprintf("%02X", s.AF&0xFF),
+		"B":  fmt.Sprintf("%02X", s.BC>>8),
+		"C":  fmt.Sprintf("%02X", s.BC&0xFF),
+		"D":  fmt.Sprintf("%02X", s.DE>>8),
+		"E":  fmt.Sprintf("%02X", s.DE&0xFF),
+		"H":  fmt.Sprintf("%02X", s.HL>>8),
+		"L":  fmt.Sprintf("%02X", s.HL&0xFF),
+		"AF": fmt.Sprintf("%04X", s.AF),
+		"BC": fmt.Sprintf("%04X", s.BC),
+		"DE": fmt.Sprintf("%04X", s.DE),
+		"HL": fmt.Sprintf("%04X", s.HL),
+		"SP": fmt.Sprintf("%04X", s.SP),
+		"PC": fmt.Sprintf("%04X", s.PC),
+	}
+
+	var out strings.Builder
+	for {
+		start := strings.IndexByte(message, '%')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(message[start+1:], '%')
+		if end < 0 {
+			break
+		}
+		end += start + 1
+
+		name := strings.ToUpper(message[start+1 : end])
+		value, ok := values[name]
+		if !ok {
+			// not an expression, keep the % and look again after it
+			out.WriteString(message[:start+1])
+			message = message[start+1:]
+			continue
+		}
+
+		out.WriteString(message[:start])
+		out.WriteString(value)
+		message = message[end+1:]
+	}
+	out.WriteString(message)
+
+	return out.String()
+}
@@ -0,0 +1,46 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+func TestOpcodeHooks(t *testing.T) {
+	s := scheduler.New()
+	bus := &flatMMU{scheduler: s, interrupts: mmu.InterruptControllerNew()}
+	c := New(bus, s)
+	c.Init()
+
+	program := []byte{
+		0x06, 0x03, // ld b,$03
+		0x52,       // ld d,d
+		0x18, 0x0E, // jr .end
+		0x64, 0x64, 0x00, 0x00,
+		'B', ':', ' ', '%', 'B', '%', ' ', '1', '0', '%',
+		0x40, // .end: ld b,b
+	}
+	copy(bus.memory[0x0100:], program)
+
+	var messages []string
+	var breakpoint *State
+	c.OnDebugMessage(func(message string) {
+		messages = append(messages, message)
+	})
+	c.OnBreakpoint(func(s State) {
+		breakpoint = &s
+	})
+
+	for i := 0; i < 4; i++ {
+		c.Cycle()
+	}
+
+	if len(messages) != 1 || messages[0] != "B: 03 10%" {
+		t.Errorf("debug messages = %q", messages)
+	}
+
+	if breakpoint == nil || breakpoint.PC != 0x0113 || breakpoint.BC>>8 != 0x03 {
+		t.Errorf("breakpoint = %+v", breakpoint)
+	}
+}
@@ -29,7 +29,7 @@ Load to the 8-bit register r, the immediate data n.
 Machine Cycles: 2
 */
 func (m *instructions) ld_r_n(cpu *CPU, r *uint8) uint32 {
-	*r = cpu.mmu.RB(cpu.popPC())
+	*r = cpu.read(cpu.popPC())
 	return 2
 }
 
@@ -42,7 +42,7 @@ Machine Cycles: 2
 */
 func (m *instructions) ld_r_HL(cpu *CPU, r *uint8) uint32 {
 	hl := uint16(cpu.register.h)<<8 | uint16(cpu.register.l)
-	*r = cpu.mmu.RB(hl)
+	*r = cpu.read(hl)
 	return 2
 }
 
@@ -55,7 +55,7 @@ Machine Cycles: 2
 */
 func (m *instructions) ld_HL_r(cpu *CPU, r *uint8) uint32 {
 	hl := uint16(cpu.register.h)<<8 | uint16(cpu.register.l)
-	cpu.mmu.WB(hl, *r)
+	cpu.write(hl, *r)
 	return 2
 }
 
@@ -68,8 +68,8 @@ Machine Cycles: 3
 */
 func (m *instructions) ld_HL_n(cpu *CPU) uint32 {
 	hl := uint16(cpu.register.h)<<8 | uint16(cpu.register.l)
-	n := cpu.mmu.RB(cpu.popPC())
-	cpu.mmu.WB(hl, n)
+	n := cpu.read(cpu.popPC())
+	cpu.write(hl, n)
 	return 3
 }
 
@@ -82,7 +82,7 @@ Machine Cycles: 2
 */
 func (m *instructions) ld_A_BC(cpu *CPU) uint32 {
 	bc := uint16(cpu.register.b)<<8 | uint16(cpu.register.c)
-	cpu.register.a = cpu.mmu.RB(bc)
+	cpu.register.a = cpu.read(bc)
 	return 2
 }
 
@@ -95,7 +95,7 @@ Machine Cycles: 2
 */
 func (m *instructions) ld_A_DE(cpu *CPU) uint32 {
 	de := uint16(cpu.register.d)<<8 | uint16(cpu.register.e)
-	cpu.register.a = cpu.mmu.RB(de)
+	cpu.register.a = cpu.read(de)
 	return 2
 }
 
@@ -108,7 +108,7 @@ Machine Cycles: 2
 */
 func (m *instructions) ld_BC_A(cpu *CPU) uint32 {
 	bc := uint16(cpu.register.b)<<8 | uint16(cpu.register.c)
-	cpu.mmu.WB(bc, cpu.register.a)
+	cpu.write(bc, cpu.register.a)
 	return 2
 }
 
@@ -121,7 +121,7 @@ Machine Cycles: 2
 */
 func (m *instructions) ld_DE_A(cpu *CPU) uint32 {
 	de := uint16(cpu.register.d)<<8 | uint16(cpu.register.e)
-	cpu.mmu.WB(de, cpu.register.a)
+	cpu.write(de, cpu.register.a)
 	return 2
 }
 
@@ -133,10 +133,10 @@ Load to the 8-bit A register, data from the absolute address specified by the 16
 Machine Cycles: 4
 */
 func (m *instructions) ld_A_nn(cpu *CPU) uint32 {
-	lsb := cpu.mmu.RB(cpu.popPC())
-	msb := cpu.mmu.RB(cpu.popPC())
+	lsb := cpu.read(cpu.popPC())
+	msb := cpu.read(cpu.popPC())
 	addr := uint16(msb)<<8 | uint16(lsb)
-	cpu.register.a = cpu.mmu.RB(addr)
+	cpu.register.a = cpu.read(addr)
 	return 4
 }
 
@@ -148,10 +148,10 @@ Load to the absolute address specified by the 16-bit operand nn, data from the 8
 Machine Cycles: 4
 */
 func (m *instructions) ld_nn_A(cpu *CPU) uint32 {
-	lsb := cpu.mmu.RB(cpu.popPC())
-	msb := cpu.mmu.RB(cpu.popPC())
+	lsb := cpu.read(cpu.popPC())
+	msb := cpu.read(cpu.popPC())
 	addr := uint16(msb)<<8 | uint16(lsb)
-	cpu.mmu.WB(addr, cpu.register.a)
+	cpu.write(addr, cpu.register.a)
 	return 4
 }
 
@@ -165,7 +165,7 @@ significant byte to the value of C, so the possible range is 0xFF00-0xFFFF.
 Machine Cycles: 2
 */
 func (m *instructions) ldh_A_C(cpu *CPU) uint32 {
-	cpu.register.a = cpu.mmu.RB(0xFF00 | uint16(cpu.register.c))
+	cpu.register.a = cpu.read(0xFF00 | uint16(cpu.register.c))
 	return 2
 }
 
@@ -179,7 +179,7 @@ significant byte to the value of C, so the possible range is 0xFF00-0xFFFF.
 Machine Cycles: 2
 */
 func (m *instructions) ldh_C_A(cpu *CPU) uint32 {
-	cpu.mmu.WB(0xFF00|uint16(cpu.register.c), cpu.register.a)
+	cpu.write(0xFF00|uint16(cpu.register.c), cpu.register.a)
 	return 2
 }
 
@@ -193,8 +193,8 @@ least significant byte to the value of n, so the possible range is 0xFF00-0xFFFF
 Machine Cycles: 3
 */
 func (m *instructions) ldh_A_n(cpu *CPU) uint32 {
-	n := cpu.mmu.RB(cpu.popPC())
-	cpu.register.a = cpu.mmu.RB(0xFF00 | uint16(n))
+	n := cpu.read(cpu.popPC())
+	cpu.register.a = cpu.read(0xFF00 | uint16(n))
 	return 3
 }
 
@@ -208,8 +208,8 @@ least significant byte to the value of n, so the possible range is 0xFF00-0xFFFF
 Machine Cycles: 3
 */
 func (m *instructions) ldh_n_A(cpu *CPU) uint32 {
-	n := cpu.mmu.RB(cpu.popPC())
-	cpu.mmu.WB(0xFF00|uint16(n), cpu.register.a)
+	n := cpu.read(cpu.popPC())
+	cpu.write(0xFF00|uint16(n), cpu.register.a)
 	return 3
 }
 
@@ -226,7 +226,7 @@ func (m *instructions) ld_A_HLd(cpu *CPU) uint32 {
 	value := hl - 1
 	cpu.register.h = uint8(value >> 8)
 	cpu.register.l = uint8(value & 0x00FF)
-	cpu.register.a = cpu.mmu.RB(hl)
+	cpu.register.a = cpu.read(hl)
 	return 2
 }
 
@@ -240,7 +240,7 @@ Machine Cycles: 2
 */
 func (m *instructions) ld_HLd_A(cpu *CPU) uint32 {
 	hl := uint16(cpu.register.h)<<8 | uint16(cpu.register.l)
-	cpu.mmu.WB(hl, cpu.register.a)
+	cpu.write(hl, cpu.register.a)
 
 	value := uint16(int16(hl) - 1)
 	cpu.register.h = uint8(value >> 8)
@@ -259,7 +259,7 @@ Machine Cycles: 2
 */
 func (m *instructions) ld_A_HLi(cpu *CPU) uint32 {
 	hl := uint16(cpu.register.h)<<8 | uint16(cpu.register.l)
-	cpu.register.a = cpu.mmu.RB(hl)
+	cpu.register.a = cpu.read(hl)
 
 	value := hl + 1
 	cpu.register.h = uint8(value >> 8)
@@ -277,7 +277,7 @@ Machine Cycles: 2
 */
 func (m *instructions) ld_HLi_A(cpu *CPU) uint32 {
 	hl := uint16(cpu.register.h)<<8 | uint16(cpu.register.l)
-	cpu.mmu.WB(hl, cpu.register.a)
+	cpu.write(hl, cpu.register.a)
 	value := hl + 1
 	cpu.register.h = uint8(value >> 8)
 	cpu.register.l = uint8(value & 0x00FF)
@@ -310,13 +310,9 @@ Load to the absolute address specified by the 16-bit operand nn, data from the 1
 Machine Cycles: 5
 */
 func (m *instructions) ld_nn_sp(cpu *CPU) uint32 {
-	word := cpu.rw(cpu.PC)
+	nn := cpu.rw(cpu.PC)
 
-	sp_msb := uint8(cpu.SP >> 8)
-	sp_lsb := uint8(cpu.SP & 0x00FF)
-	cpu.mmu.WB(word, sp_lsb)
-	word += 1
-	cpu.mmu.WB(word, sp_msb)
+	cpu.writeWord(nn, cpu.SP)
 	return 5
 }
 
@@ -351,10 +347,11 @@ Push to the stack memory, data from the 16-bit register rr.
 Machine Cycles: 4
 */
 func (m *instructions) ld_push_rr(cpu *CPU, r1 *uint8, r2 *uint8) uint32 {
+	cpu.incDec(cpu.SP)
 	cpu.SP -= 1
-	cpu.mmu.WB(cpu.SP, *r1)
+	cpu.write(cpu.SP, *r1)
 	cpu.SP -= 1
-	cpu.mmu.WB(cpu.SP, *r2)
+	cpu.write(cpu.SP, *r2)
 	return 4
 }
 
@@ -368,8 +365,11 @@ F register value, so all flags are changed based on the 8-bit data that is read
 Machine Cycles: 3
 */
 func (m *instructions) ld_pop_rr(cpu *CPU, r1 *uint8, r2 *uint8, removeLowerNibble bool) uint32 {
-	word := cpu.mmu.RW(cpu.SP)
-	cpu.SP += 2
+	lsb := cpu.readIncDec(cpu.SP)
+	cpu.SP += 1
+	msb := cpu.readIncDec(cpu.SP)
+	cpu.SP += 1
+	word := uint16(msb)<<8 | uint16(lsb)
 
 	lowerMask := uint16(0x00FF)
 	if removeLowerNibble {
@@ -390,16 +390,18 @@ bit value of the SP register.
 Machine Cycles: 3
 */
 func (m *instructions) ld_HL_spe(cpu *CPU) uint32 {
-	e := cpu.mmu.RB(cpu.popPC())
+	e := cpu.read(cpu.popPC())
 	result := uint16(int32(cpu.SP) + int32(int8(e)))
 
 	cpu.register.h = uint8(result >> 8)
 	cpu.register.l = uint8(result & 0xFF)
 
-	cpu.register.setFlag("Z", false)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", ((cpu.SP&0x000F)+(uint16(e)&0x000F)) > 0x000F)
-	cpu.register.setFlag("C", ((cpu.SP&0x00FF)+(uint16(e)&0x00FF)) > 0x00FF)
+	cpu.register.setFlags(
+		false,
+		false,
+		((cpu.SP&0x000F)+(uint16(e)&0x000F)) > 0x000F,
+		((cpu.SP&0x00FF)+(uint16(e)&0x00FF)) > 0x00FF,
+	)
 	return 3
 }
 
@@ -415,10 +417,12 @@ Machine Cycles: 1
 func (m *instructions) add_r(cpu *CPU, r *uint8) uint32 {
 	sum := cpu.register.a + *r
 
-	cpu.register.setFlag("Z", sum == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (cpu.register.a&0xF)+(*r&0xF) > 0xF)
-	cpu.register.setFlag("C", uint16(cpu.register.a)+uint16(*r) > 0xFF)
+	cpu.register.setFlags(
+		sum == 0x0,
+		false,
+		(cpu.register.a&0xF)+(*r&0xF) > 0xF,
+		uint16(cpu.register.a)+uint16(*r) > 0xFF,
+	)
 	cpu.register.a = sum
 
 	return 1
@@ -436,14 +440,11 @@ func (m *instructions) add_HL(cpu *CPU) uint32 {
 	hl := uint16(cpu.register.h)<<8 | uint16(cpu.register.l)
 	a := cpu.register.a
 
-	n := cpu.mmu.RB(hl)
+	n := cpu.read(hl)
 
 	sum := a + n
 
-	cpu.register.setFlag("Z", sum == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (a&0xF)+(n&0xF) > 0xF)
-	cpu.register.setFlag("C", uint16(a)+uint16(n) > 0xFF)
+	cpu.register.setFlags(sum == 0x0, false, (a&0xF)+(n&0xF) > 0xF, uint16(a)+uint16(n) > 0xFF)
 	cpu.register.a = sum
 
 	return 2
@@ -457,17 +458,14 @@ Adds to the 8-bit A register, the immediate data n, and stores the result back i
 Machine Cycles: 2
 */
 func (m *instructions) add_n(cpu *CPU) uint32 {
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 	a := cpu.register.a
 
 	sum := a + n
 
 	cpu.register.a = sum
 
-	cpu.register.setFlag("Z", sum == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (a&0xF)+(n&0xF) > 0xF)
-	cpu.register.setFlag("C", uint16(a)+uint16(n) > 0xFF)
+	cpu.register.setFlags(sum == 0x0, false, (a&0xF)+(n&0xF) > 0xF, uint16(a)+uint16(n) > 0xFF)
 	return 2
 }
 
@@ -481,7 +479,7 @@ Machine Cycles: 1
 */
 func (m *instructions) adc_r(cpu *CPU, r *uint8) uint32 {
 	c := uint8(0)
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
@@ -489,10 +487,12 @@ func (m *instructions) adc_r(cpu *CPU, r *uint8) uint32 {
 
 	sum := a + *r + c
 
-	cpu.register.setFlag("Z", sum == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (a&0xF)+(*r&0xF)+c > 0xF)
-	cpu.register.setFlag("C", uint16(a)+uint16(*r)+uint16(c) > 0xFF)
+	cpu.register.setFlags(
+		sum == 0x0,
+		false,
+		(a&0xF)+(*r&0xF)+c > 0xF,
+		uint16(a)+uint16(*r)+uint16(c) > 0xFF,
+	)
 	cpu.register.a = sum
 
 	return 1
@@ -509,21 +509,23 @@ Machine Cycles: 2
 func (m *instructions) adc_HL(cpu *CPU) uint32 {
 	hl := uint16(cpu.register.h)<<8 | uint16(cpu.register.l)
 	c := uint8(0)
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
-	n := cpu.mmu.RB(hl)
+	n := cpu.read(hl)
 	a := cpu.register.a
 
 	sum := a + n + c
 
 	cpu.register.a = sum
 
-	cpu.register.setFlag("Z", sum == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (a&0xF)+(n&0xF)+c > 0xF)
-	cpu.register.setFlag("C", uint16(a)+uint16(n)+uint16(c) > 0xFF)
+	cpu.register.setFlags(
+		sum == 0x0,
+		false,
+		(a&0xF)+(n&0xF)+c > 0xF,
+		uint16(a)+uint16(n)+uint16(c) > 0xFF,
+	)
 
 	return 2
 }
@@ -538,21 +540,23 @@ Machine Cycles: 2
 */
 func (m *instructions) adc_n(cpu *CPU) uint32 {
 	c := uint8(0)
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 	a := cpu.register.a
 
 	sum := a + n + c
 
 	cpu.register.a = sum
 
-	cpu.register.setFlag("Z", sum == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (a&0xF)+(n&0xF)+c > 0xF)
-	cpu.register.setFlag("C", uint16(a)+uint16(n)+uint16(c) > 0xFF)
+	cpu.register.setFlags(
+		sum == 0x0,
+		false,
+		(a&0xF)+(n&0xF)+c > 0xF,
+		uint16(a)+uint16(n)+uint16(c) > 0xFF,
+	)
 
 	return 2
 }
@@ -569,10 +573,7 @@ func (m *instructions) sub_r(cpu *CPU, r *uint8) uint32 {
 	a := cpu.register.a
 	res := a - *r
 
-	cpu.register.setFlag("Z", res == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (a&0x0F) < (*r&0x0F))
-	cpu.register.setFlag("C", uint16(a) < uint16(*r))
+	cpu.register.setFlags(res == 0x0, true, (a&0x0F) < (*r&0x0F), uint16(a) < uint16(*r))
 
 	cpu.register.a = res
 
@@ -593,16 +594,13 @@ func (m *instructions) sub_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	n := cpu.mmu.RB(hl)
+	n := cpu.read(hl)
 
 	r := a - n
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (a&0x0F) < (n&0x0F))
-	cpu.register.setFlag("C", uint16(a) < uint16(n))
+	cpu.register.setFlags(r == 0x0, true, (a&0x0F) < (n&0x0F), uint16(a) < uint16(n))
 
 	return 2
 }
@@ -617,16 +615,13 @@ Machine Cycles: 2
 */
 func (m *instructions) sub_n(cpu *CPU) uint32 {
 	a := cpu.register.a
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 
 	r := a - n
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (a&0x0F) < (n&0x0F))
-	cpu.register.setFlag("C", uint16(a) < uint16(n))
+	cpu.register.setFlags(r == 0x0, true, (a&0x0F) < (n&0x0F), uint16(a) < uint16(n))
 
 	return 2
 }
@@ -642,16 +637,18 @@ Machine Cycles: 1
 func (m *instructions) sbc_r(cpu *CPU, r *uint8) uint32 {
 	a := cpu.register.a
 	c := uint8(0)
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
 	res := a - *r - c
 
-	cpu.register.setFlag("Z", res == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (a&0x0F) < ((*r&0x0F)+c))
-	cpu.register.setFlag("C", uint16(a) < (uint16(*r)+uint16(c)))
+	cpu.register.setFlags(
+		res == 0x0,
+		true,
+		(a&0x0F) < ((*r&0x0F)+c),
+		uint16(a) < (uint16(*r)+uint16(c)),
+	)
 
 	cpu.register.a = res
 
@@ -673,20 +670,22 @@ func (m *instructions) sbc_HL(cpu *CPU) uint32 {
 	hl := uint16(h)<<8 | uint16(l)
 
 	c := uint8(0)
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
-	n := cpu.mmu.RB(hl)
+	n := cpu.read(hl)
 
 	r := a - n - c
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (a&0x0F) < ((n&0x0F)+c))
-	cpu.register.setFlag("C", uint16(a) < (uint16(n)+uint16(c)))
+	cpu.register.setFlags(
+		r == 0x0,
+		true,
+		(a&0x0F) < ((n&0x0F)+c),
+		uint16(a) < (uint16(n)+uint16(c)),
+	)
 
 	return 2
 }
@@ -703,20 +702,22 @@ func (m *instructions) sbc_n(cpu *CPU) uint32 {
 	a := cpu.register.a
 
 	c := uint8(0)
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 
 	r := a - n - c
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (a&0x0F) < ((n&0x0F)+c))
-	cpu.register.setFlag("C", uint16(a) < (uint16(n)+uint16(c)))
+	cpu.register.setFlags(
+		r == 0x0,
+		true,
+		(a&0x0F) < ((n&0x0F)+c),
+		uint16(a) < (uint16(n)+uint16(c)),
+	)
 
 	return 2
 }
@@ -733,10 +734,7 @@ func (m *instructions) cp_A_r(cpu *CPU, r *uint8) uint32 {
 	a := cpu.register.a
 	v := a - *r
 
-	cpu.register.setFlag("Z", v == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (a&0x0F) < (*r&0x0F))
-	cpu.register.setFlag("C", uint16(a) < uint16(*r))
+	cpu.register.setFlags(v == 0x0, true, (a&0x0F) < (*r&0x0F), uint16(a) < uint16(*r))
 
 	return 1
 }
@@ -756,14 +754,11 @@ func (m *instructions) cp_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	n := cpu.mmu.RB(hl)
+	n := cpu.read(hl)
 
 	r := a - n
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (a&0x0F) < (n&0x0F))
-	cpu.register.setFlag("C", uint16(a) < uint16(n))
+	cpu.register.setFlags(r == 0x0, true, (a&0x0F) < (n&0x0F), uint16(a) < uint16(n))
 
 	return 2
 }
@@ -778,14 +773,11 @@ Machine Cycles: 2
 */
 func (m *instructions) cp_n(cpu *CPU) uint32 {
 	a := cpu.register.a
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 
 	r := a - n
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (a&0x0F) < (n&0x0F))
-	cpu.register.setFlag("C", uint16(a) < uint16(n))
+	cpu.register.setFlags(r == 0x0, true, (a&0x0F) < (n&0x0F), uint16(a) < uint16(n))
 
 	return 2
 }
@@ -801,9 +793,9 @@ func (m *instructions) inc_r(cpu *CPU, r *uint8) uint32 {
 	v := *r
 	*r = v + 1
 
-	cpu.register.setFlag("Z", *r == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (v&0x0F)+1 > 0x0F)
+	cpu.register.setFlag(flagZ, *r == 0x0)
+	cpu.register.setFlag(flagN, false)
+	cpu.register.setFlag(flagH, (v&0x0F)+1 > 0x0F)
 
 	return 1
 }
@@ -820,15 +812,15 @@ func (m *instructions) inc_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	r := data + 1
 
-	cpu.mmu.WB(hl, r)
+	cpu.write(hl, r)
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (data&0x0F)+1 > 0x0F)
+	cpu.register.setFlag(flagZ, r == 0x0)
+	cpu.register.setFlag(flagN, false)
+	cpu.register.setFlag(flagH, (data&0x0F)+1 > 0x0F)
 
 	return 3
 }
@@ -845,9 +837,9 @@ func (m *instructions) dec_r(cpu *CPU, r *uint8) uint32 {
 
 	*r = uint8(uint16(v) - 1)
 
-	cpu.register.setFlag("Z", *r == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (v&0x0F)-1 > 0x0F)
+	cpu.register.setFlag(flagZ, *r == 0x0)
+	cpu.register.setFlag(flagN, true)
+	cpu.register.setFlag(flagH, (v&0x0F)-1 > 0x0F)
 
 	return 1
 }
@@ -864,15 +856,15 @@ func (m *instructions) dec_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	r := data - 1
 
-	cpu.mmu.WB(hl, r)
+	cpu.write(hl, r)
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", (data&0x0F)-1 > 0x0F)
+	cpu.register.setFlag(flagZ, r == 0x0)
+	cpu.register.setFlag(flagN, true)
+	cpu.register.setFlag(flagH, (data&0x0F)-1 > 0x0F)
 
 	return 3
 }
@@ -890,10 +882,7 @@ func (m *instructions) and_r(cpu *CPU, r *uint8) uint32 {
 
 	res := a & *r
 
-	cpu.register.setFlag("Z", res == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", true)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(res == 0x0, false, true, false)
 
 	cpu.register.a = res
 
@@ -914,16 +903,13 @@ func (m *instructions) and_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	r := a & data
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", true)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(r == 0x0, false, true, false)
 
 	return 2
 }
@@ -938,16 +924,13 @@ Machine Cycles: 2
 */
 func (m *instructions) and_n(cpu *CPU) uint32 {
 	a := cpu.register.a
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 
 	r := a & n
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", true)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(r == 0x0, false, true, false)
 
 	return 2
 }
@@ -965,10 +948,7 @@ func (m *instructions) or_a_r(cpu *CPU, r *uint8) uint32 {
 
 	cpu.register.a = v
 
-	cpu.register.setFlag("Z", v == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(v == 0x0, false, false, false)
 
 	return 1
 }
@@ -988,16 +968,13 @@ func (m *instructions) or_a_HL(cpu *CPU) uint32 {
 
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	r := a | data
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(r == 0x0, false, false, false)
 
 	return 2
 }
@@ -1012,15 +989,12 @@ Machine Cycles: 2
 */
 func (m *instructions) or_n(cpu *CPU) uint32 {
 	a := cpu.register.a
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 	r := a | n
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(r == 0x0, false, false, false)
 
 	return 2
 }
@@ -1040,16 +1014,13 @@ func (m *instructions) xor_HL(cpu *CPU) uint32 {
 
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	r := a ^ data
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(r == 0x0, false, false, false)
 
 	return 2
 }
@@ -1069,10 +1040,7 @@ func (m *instructions) xor_r(cpu *CPU, register *byte) uint32 {
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(r == 0x0, false, false, false)
 
 	return 1
 }
@@ -1087,16 +1055,13 @@ Machine Cycles: 2
 */
 func (m *instructions) xor_n(cpu *CPU) uint32 {
 	a := cpu.register.a
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 
 	r := a ^ n
 
 	cpu.register.a = r
 
-	cpu.register.setFlag("Z", r == 0x0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(r == 0x0, false, false, false)
 
 	return 2
 }
@@ -1109,9 +1074,9 @@ Flips the carry flag, and clears the N and H flags.
 Machine Cycles: 1
 */
 func (m *instructions) ccf(cpu *CPU) uint32 {
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", !cpu.register.getFlag("C"))
+	cpu.register.setFlag(flagN, false)
+	cpu.register.setFlag(flagH, false)
+	cpu.register.setFlag(flagC, !cpu.register.getFlag(flagC))
 
 	return 1
 }
@@ -1124,9 +1089,9 @@ Sets the carry flag, and clears the N and H flags.
 Machine Cycles: 1
 */
 func (m *instructions) scf(cpu *CPU) uint32 {
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", true)
+	cpu.register.setFlag(flagN, false)
+	cpu.register.setFlag(flagH, false)
+	cpu.register.setFlag(flagC, true)
 
 	return 1
 }
@@ -1141,10 +1106,10 @@ Machine Cycles: 1
 func (m *instructions) daa(cpu *CPU) uint32 {
 	adjustment := uint8(0x0)
 
-	carry := cpu.register.getFlag("C")
+	carry := cpu.register.getFlag(flagC)
 
-	if cpu.register.getFlag("N") {
-		if cpu.register.getFlag("H") {
+	if cpu.register.getFlag(flagN) {
+		if cpu.register.getFlag(flagH) {
 			adjustment += 0x6
 
 		}
@@ -1154,7 +1119,7 @@ func (m *instructions) daa(cpu *CPU) uint32 {
 
 		cpu.register.a -= adjustment
 	} else {
-		if cpu.register.getFlag("H") || cpu.register.a&0xF > 0x9 {
+		if cpu.register.getFlag(flagH) || cpu.register.a&0xF > 0x9 {
 			adjustment += 0x6
 		}
 
@@ -1169,9 +1134,9 @@ func (m *instructions) daa(cpu *CPU) uint32 {
 
 	resultIsZero := cpu.register.a == 0x0
 
-	cpu.register.setFlag("C", carry)
-	cpu.register.setFlag("Z", resultIsZero)
-	cpu.register.setFlag("H", false)
+	cpu.register.setFlag(flagC, carry)
+	cpu.register.setFlag(flagZ, resultIsZero)
+	cpu.register.setFlag(flagH, false)
 	return 1
 }
 
@@ -1185,8 +1150,8 @@ Machine Cycles: 1
 func (m *instructions) cpl(cpu *CPU) uint32 {
 
 	cpu.register.a = ^cpu.register.a
-	cpu.register.setFlag("N", true)
-	cpu.register.setFlag("H", true)
+	cpu.register.setFlag(flagN, true)
+	cpu.register.setFlag(flagH, true)
 	return 1
 }
 
@@ -1202,6 +1167,7 @@ Machine Cycles: 2
 func (m *instructions) inc_rr(cpu *CPU, r1 *uint8, r2 *uint8) uint32 {
 	rr := uint16(*r1)<<8 | uint16(*r2)
 
+	cpu.incDec(rr)
 	rr += 1
 
 	*r1 = uint8(rr >> 8)
@@ -1221,6 +1187,7 @@ func (m *instructions) dec_rr(cpu *CPU, r1 *uint8, r2 *uint8) uint32 {
 
 	rr := uint16(*r1)<<8 | uint16(*r2)
 
+	cpu.incDec(rr)
 	rr -= 1
 
 	*r1 = uint8(rr >> 8)
@@ -1245,9 +1212,9 @@ func (m *instructions) add_HL_rr(cpu *CPU, r1 *uint8, r2 *uint8) uint32 {
 	hl := uint16(h)<<8 | uint16(l)
 
 	r := hl + rr
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (hl&0x0FFF)+(rr&0x0FFF) > 0x0FFF)
-	cpu.register.setFlag("C", rr > 0xFFFF-hl)
+	cpu.register.setFlag(flagN, false)
+	cpu.register.setFlag(flagH, (hl&0x0FFF)+(rr&0x0FFF) > 0x0FFF)
+	cpu.register.setFlag(flagC, rr > 0xFFFF-hl)
 
 	cpu.register.h = uint8(r >> 8)
 	cpu.register.l = uint8(r & 0x00FF)
@@ -1264,14 +1231,16 @@ the 16-bit value of the SP register.
 Machine Cycles: 4
 */
 func (m *instructions) add_sp_e(cpu *CPU) uint32 {
-	e := cpu.mmu.RB(cpu.popPC())
+	e := cpu.read(cpu.popPC())
 
 	r := int16(cpu.SP) + int16(int8(e))
 
-	cpu.register.setFlag("Z", false)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", (cpu.SP&0x0F)+(uint16(e)&0x0F) > 0x0F)
-	cpu.register.setFlag("C", (cpu.SP&0xFF)+(uint16(e)&0xFF) > 0xFF)
+	cpu.register.setFlags(
+		false,
+		false,
+		(cpu.SP&0x0F)+(uint16(e)&0x0F) > 0x0F,
+		(cpu.SP&0xFF)+(uint16(e)&0xFF) > 0xFF,
+	)
 
 	cpu.SP = uint16(r)
 
@@ -1295,10 +1264,7 @@ func (m *instructions) rlca(cpu *CPU) uint32 {
 
 	b7 := (a & (1 << 7)) >> 7
 
-	cpu.register.setFlag("Z", false)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b7 != 0)
+	cpu.register.setFlags(false, false, false, b7 != 0)
 
 	cpu.register.a = a<<1 | b7
 
@@ -1320,10 +1286,7 @@ func (m *instructions) rrca(cpu *CPU) uint32 {
 
 	b0 := (a & (1 << 0))
 
-	cpu.register.setFlag("Z", false)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b0 != 0)
+	cpu.register.setFlags(false, false, false, b0 != 0)
 
 	cpu.register.a = a>>1 | (b0 << 7)
 
@@ -1342,7 +1305,7 @@ Machine Cycles: 1
 */
 func (m *instructions) rla(cpu *CPU) uint32 {
 	c := uint8(0)
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
@@ -1350,10 +1313,7 @@ func (m *instructions) rla(cpu *CPU) uint32 {
 
 	b7 := (a & (1 << 7)) >> 7
 
-	cpu.register.setFlag("Z", false)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b7 != 0)
+	cpu.register.setFlags(false, false, false, b7 != 0)
 
 	cpu.register.a = a<<1 | c
 
@@ -1372,17 +1332,14 @@ Machine Cycles: 1
 */
 func (m *instructions) rra(cpu *CPU) uint32 {
 	c := uint8(0)
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 0x80 // 10000000
 	}
 	a := cpu.register.a
 
 	b0 := (a & (1 << 0))
 
-	cpu.register.setFlag("Z", false)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b0 != 0)
+	cpu.register.setFlags(false, false, false, b0 != 0)
 
 	cpu.register.a = a>>1 | c
 
@@ -1404,10 +1361,7 @@ func (m *instructions) rlc_r(cpu *CPU, register *uint8) uint32 {
 
 	res := r<<1 | b7
 
-	cpu.register.setFlag("Z", res == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b7 != 0)
+	cpu.register.setFlags(res == 0, false, false, b7 != 0)
 
 	*register = res
 
@@ -1429,17 +1383,14 @@ func (m *instructions) rlc_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	b7 := (data & (1 << 7)) >> 7
 	res := data<<1 | b7
 
-	cpu.register.setFlag("Z", res == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b7 != 0)
+	cpu.register.setFlags(res == 0, false, false, b7 != 0)
 
-	cpu.mmu.WB(hl, res)
+	cpu.write(hl, res)
 
 	return 4
 }
@@ -1459,10 +1410,7 @@ func (m *instructions) rrc_r(cpu *CPU, register *uint8) uint32 {
 	b0 := (r & (0x01 << 0))
 	res := (r >> 1) | (b0 << 7)
 
-	cpu.register.setFlag("Z", res == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b0 != 0)
+	cpu.register.setFlags(res == 0, false, false, b0 != 0)
 
 	*register = res
 
@@ -1484,16 +1432,14 @@ func (m *instructions) rrc_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
-	b0 := (data & (1 << 0)) << 7
+	b0 := data & (1 << 0)
+	res := data>>1 | b0<<7
 
-	cpu.register.setFlag("Z", b0 == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b0 != 0)
+	cpu.register.setFlags(res == 0, false, false, b0 != 0)
 
-	cpu.register.b = data>>1 | b0
+	cpu.write(hl, res)
 
 	return 4
 }
@@ -1511,17 +1457,14 @@ func (m *instructions) rl_r(cpu *CPU, register *uint8) uint32 {
 	r := *register
 	c := uint8(0)
 
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
 	b7 := r & (1 << 7)
 	result := r<<1 | c
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b7 != 0)
+	cpu.register.setFlags(result == 0, false, false, b7 != 0)
 
 	*register = result
 
@@ -1544,22 +1487,19 @@ func (m *instructions) rl_HL(cpu *CPU) uint32 {
 	hl := uint16(h)<<8 | uint16(l)
 
 	c := uint8(0)
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	b7 := data & (1 << 7)
 
 	result := data<<1 | (c >> 7)
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b7 != 0)
+	cpu.register.setFlags(result == 0, false, false, b7 != 0)
 
-	cpu.mmu.WB(hl, result)
+	cpu.write(hl, result)
 
 	return 4
 }
@@ -1577,7 +1517,7 @@ func (m *instructions) rr_r(cpu *CPU, register *uint8) uint32 {
 	r := *register
 	c := uint8(0)
 
-	if cpu.register.getFlag("c") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
@@ -1585,10 +1525,7 @@ func (m *instructions) rr_r(cpu *CPU, register *uint8) uint32 {
 
 	result := r>>1 | (c << 7)
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b0 != 0)
+	cpu.register.setFlags(result == 0, false, false, b0 != 0)
 
 	*register = result
 
@@ -1609,10 +1546,10 @@ func (m *instructions) rr_HL(cpu *CPU) uint32 {
 	h := cpu.register.h
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	c := uint8(0)
-	if cpu.register.getFlag("c") {
+	if cpu.register.getFlag(flagC) {
 		c = 1
 	}
 
@@ -1620,12 +1557,9 @@ func (m *instructions) rr_HL(cpu *CPU) uint32 {
 
 	result := data>>1 | c<<7
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b0 != 0)
+	cpu.register.setFlags(result == 0, false, false, b0 != 0)
 
-	cpu.mmu.WB(hl, result)
+	cpu.write(hl, result)
 
 	return 4
 }
@@ -1645,10 +1579,7 @@ func (m *instructions) sla_r(cpu *CPU, register *uint8) uint32 {
 
 	result := r << 1
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b7 != 0)
+	cpu.register.setFlags(result == 0, false, false, b7 != 0)
 
 	*register = result
 
@@ -1669,17 +1600,14 @@ func (m *instructions) sla_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	b7 := data & (1 << 7)
 	result := data << 1
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", b7 != 0)
+	cpu.register.setFlags(result == 0, false, false, b7 != 0)
 
-	cpu.mmu.WB(hl, result)
+	cpu.write(hl, result)
 
 	return 4
 }
@@ -1700,10 +1628,7 @@ func (m *instructions) sra_r(cpu *CPU, register *uint8) uint32 {
 
 	result := r>>1 | msb
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", lsb != 0)
+	cpu.register.setFlags(result == 0, false, false, lsb != 0)
 
 	*register = result
 
@@ -1715,7 +1640,7 @@ func (m *instructions) sra_r(cpu *CPU, register *uint8) uint32 {
 
 Shifts, the 8-bit value at the address specified by the HL register, right by one bit using an
 arithmetic shift.
-Bit 7 is set to a fixed value of 0, and bit 0 is shifted to the carry flag.
+Bit 7 retains its value, and bit 0 is shifted to the carry flag.
 
 Machine Cycles: 4
 */
@@ -1724,18 +1649,16 @@ func (m *instructions) sra_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
+	msb := data & (1 << 7)
 	lsb := data & (1 << 0)
 
-	result := data >> 1
+	result := data>>1 | msb
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", lsb != 0)
+	cpu.register.setFlags(result == 0, false, false, lsb != 0)
 
-	cpu.register.b = result
+	cpu.write(hl, result)
 
 	return 4
 }
@@ -1752,10 +1675,7 @@ func (m *instructions) swap_r(cpu *CPU, register *uint8) uint32 {
 
 	result := r>>4 | r<<4
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(result == 0, false, false, false)
 
 	*register = result
 
@@ -1775,16 +1695,13 @@ func (m *instructions) swap_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	result := data>>4 | data<<4
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", false)
+	cpu.register.setFlags(result == 0, false, false, false)
 
-	cpu.mmu.WB(hl, result)
+	cpu.write(hl, result)
 
 	return 4
 }
@@ -1803,10 +1720,7 @@ func (m *instructions) srl_r(cpu *CPU, register *uint8) uint32 {
 	lsb := r & (1 << 0)
 	result := r >> 1
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", lsb != 0)
+	cpu.register.setFlags(result == 0, false, false, lsb != 0)
 
 	*register = result
 
@@ -1827,17 +1741,14 @@ func (m *instructions) srl_HL(cpu *CPU) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	lsb := data & (1 << 0)
 	result := data >> 1
 
-	cpu.register.setFlag("Z", result == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", false)
-	cpu.register.setFlag("C", lsb != 0)
+	cpu.register.setFlags(result == 0, false, false, lsb != 0)
 
-	cpu.mmu.WB(hl, result)
+	cpu.write(hl, result)
 
 	return 4
 }
@@ -1855,9 +1766,9 @@ func (m *instructions) bit_u3_r(cpu *CPU, u3 byte, register *uint8) uint32 {
 
 	b0 := r & (1 << u3)
 
-	cpu.register.setFlag("Z", b0 == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", true)
+	cpu.register.setFlag(flagZ, b0 == 0)
+	cpu.register.setFlag(flagN, false)
+	cpu.register.setFlag(flagH, true)
 
 	return 2
 }
@@ -1877,13 +1788,13 @@ func (m *instructions) bit_u3_HL(cpu *CPU, u3 byte) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	b0 := data << (1 << u3)
 
-	cpu.register.setFlag("Z", b0 == 0)
-	cpu.register.setFlag("N", false)
-	cpu.register.setFlag("H", true)
+	cpu.register.setFlag(flagZ, b0 == 0)
+	cpu.register.setFlag(flagN, false)
+	cpu.register.setFlag(flagH, true)
 
 	return 3
 }
@@ -1915,11 +1826,11 @@ func (m *instructions) res_u3_HL(cpu *CPU, bit uint8) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	result := data & ^uint8(1<<bit)
 
-	cpu.mmu.WB(hl, result)
+	cpu.write(hl, result)
 
 	return 4
 }
@@ -1951,11 +1862,11 @@ func (m *instructions) set_b_HL(cpu *CPU, bit uint8) uint32 {
 	l := cpu.register.l
 	hl := uint16(h)<<8 | uint16(l)
 
-	data := cpu.mmu.RB(hl)
+	data := cpu.read(hl)
 
 	result := data | (1 << bit)
 
-	cpu.mmu.WB(hl, result)
+	cpu.write(hl, result)
 
 	return 4
 }
@@ -2001,8 +1912,8 @@ Machine Cycles: 4 cc = true
 Machine Cycles: 3 cc = false
 */
 func (m *instructions) jp_cc_nn(cpu *CPU, cc bool) uint32 {
-	lsb := cpu.mmu.RB(cpu.popPC())
-	msb := cpu.mmu.RB(cpu.popPC())
+	lsb := cpu.read(cpu.popPC())
+	msb := cpu.read(cpu.popPC())
 
 	nn := uint16(msb)<<8 | uint16(lsb)
 
@@ -2022,7 +1933,7 @@ Unconditional jump to the relative address specified by the signed 8-bit operand
 Machine Cycles: 3
 */
 func (m *instructions) jr_e(cpu *CPU) uint32 {
-	e := cpu.mmu.RB(cpu.popPC())
+	e := cpu.read(cpu.popPC())
 
 	offset := int16(int8(e))
 
@@ -2042,9 +1953,9 @@ Machine Cycles: 3 cc = true
 Machine Cycles: 2 cc = false
 */
 func (m *instructions) jr_nz(cpu *CPU) uint32 {
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 
-	if !cpu.register.getFlag("Z") {
+	if !cpu.register.getFlag(flagZ) {
 		cpu.PC = uint16(int16(cpu.PC) + int16(int8(n)))
 		return 3
 	}
@@ -2063,9 +1974,9 @@ Machine Cycles: 3 cc = true
 Machine Cycles: 2 cc = false
 */
 func (m *instructions) jr_nc(cpu *CPU) uint32 {
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 
-	if !cpu.register.getFlag("C") {
+	if !cpu.register.getFlag(flagC) {
 		cpu.PC = uint16(int32(cpu.PC) + int32(int8(n)))
 		return 3
 	}
@@ -2084,9 +1995,9 @@ Machine Cycles: 3 cc = true
 Machine Cycles: 2 cc = false
 */
 func (m *instructions) jr_z(cpu *CPU) uint32 {
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 
-	if cpu.register.getFlag("Z") {
+	if cpu.register.getFlag(flagZ) {
 		cpu.PC = uint16(int16(cpu.PC) + int16(int8(n)))
 		return 3
 	}
@@ -2105,9 +2016,9 @@ Machine Cycles: 3 cc = true
 Machine Cycles: 2 cc = false
 */
 func (m *instructions) jr_c(cpu *CPU) uint32 {
-	n := cpu.mmu.RB(cpu.popPC())
+	n := cpu.read(cpu.popPC())
 
-	if cpu.register.getFlag("C") {
+	if cpu.register.getFlag(flagC) {
 		cpu.PC = uint16(int32(cpu.PC) + int32(int8(n)))
 		return 3
 	}
@@ -2124,11 +2035,12 @@ Machine Cycles: 6
 */
 func (m *instructions) call_nn(cpu *CPU) uint32 {
 	nn := cpu.rw(cpu.PC)
+	cpu.tick()
 
 	cpu.SP -= 1
-	cpu.mmu.WB(cpu.SP, uint8(cpu.PC>>8))
+	cpu.write(cpu.SP, uint8(cpu.PC>>8))
 	cpu.SP -= 1
-	cpu.mmu.WB(cpu.SP, uint8(cpu.PC))
+	cpu.write(cpu.SP, uint8(cpu.PC))
 
 	cpu.PC = nn
 
@@ -2146,17 +2058,18 @@ Machine Cycles: 6 cc = true
 Machine Cycles: 3 cc = false
 */
 func (m *instructions) call_cc_nn(cpu *CPU, cc bool) uint32 {
-	lsb := cpu.mmu.RB(cpu.popPC())
-	msb := cpu.mmu.RB(cpu.popPC())
+	lsb := cpu.read(cpu.popPC())
+	msb := cpu.read(cpu.popPC())
 
 	nn := uint16(msb)<<8 | uint16(lsb)
 
 	if cc {
+		cpu.tick()
 		cpu.SP -= 1
-		cpu.mmu.WB(cpu.SP, uint8(cpu.PC>>8))
+		cpu.write(cpu.SP, uint8(cpu.PC>>8))
 
 		cpu.SP -= 1
-		cpu.mmu.WB(cpu.SP, uint8(cpu.PC))
+		cpu.write(cpu.SP, uint8(cpu.PC))
 
 		cpu.PC = nn
 		return 6
@@ -2169,7 +2082,7 @@ func (m *instructions) call_cc_nn(cpu *CPU, cc bool) uint32 {
 0xC9 - RET: return
 */
 func (m *instructions) ret(cpu *CPU) uint32 {
-	cpu.PC = cpu.mmu.RW(cpu.SP)
+	cpu.PC = cpu.readWord(cpu.SP)
 	cpu.SP += 2
 
 	return 4
@@ -2184,8 +2097,11 @@ Machine Cycles: 5 cc = true
 Machine Cycles: 2 cc = false
 */
 func (m *instructions) ret_cc(cpu *CPU, cc bool) uint32 {
+	// the condition is checked in an internal machine cycle
+	cpu.tick()
+
 	if cc {
-		cpu.PC = cpu.mmu.RW(cpu.SP)
+		cpu.PC = cpu.readWord(cpu.SP)
 		cpu.SP += 2
 
 		return 5
@@ -2203,7 +2119,7 @@ Unconditional return from a function. Also enables interrupts by setting IME=1.
 Machine Cycles: 4
 */
 func (m *instructions) reti(cpu *CPU) uint32 {
-	cpu.PC = cpu.mmu.RW(cpu.SP)
+	cpu.PC = cpu.readWord(cpu.SP)
 	cpu.SP += 2
 	cpu.interrupt.IME = true
 	cpu.interrupt.EI = 0
@@ -2222,10 +2138,11 @@ func (m *instructions) rst_n(cpu *CPU, n uint8) uint32 {
 	msb := uint8(cpu.PC >> 8)
 	lsb := uint8(cpu.PC)
 
+	cpu.tick()
 	cpu.SP -= 1
-	cpu.mmu.WB(cpu.SP, msb)
+	cpu.write(cpu.SP, msb)
 	cpu.SP -= 1
-	cpu.mmu.WB(cpu.SP, lsb)
+	cpu.write(cpu.SP, lsb)
 
 	cpu.PC = uint16(n)
 
@@ -2246,6 +2163,60 @@ func (m *instructions) nop() uint32 {
 	return 1
 }
 
+/*
+0x76 - HALT: Halt system clock
+
+Stops executing instructions until an interrupt is pending (IE & IF != 0), the rest of the system
+keeps running. If IME=0 and an interrupt is already pending the CPU doesn't halt, instead the
+hardware fails to increment PC on the next opcode fetch so the byte after HALT is read twice.
+
+Machine Cycles: 1
+*/
+func (m *instructions) halt(cpu *CPU) uint32 {
+	if !cpu.interrupt.IME && cpu.interrupt.pending() != 0 {
+		cpu.haltBug = true
+		return 1
+	}
+
+	cpu.interrupt.Halt = true
+	return 1
+}
+
+/*
+0x10 - STOP: Stop system clock
+
+Enters low power mode, the second byte of the instruction is ignored. DIV is reset and both the
+CPU and the LCD are stopped until a joypad line goes low.
+On CGB, if KEY1 has the speed switch prepared, the switch is performed instead and execution
+continues.
+
+Machine Cycles: 1
+*/
+func (m *instructions) stop(cpu *CPU) uint32 {
+	cpu.popPC()
+	cpu.mmu.ResetDivider()
+
+	if cpu.mmu.SwitchSpeed() {
+		return 1
+	}
+
+	cpu.stopped = true
+	return 1
+}
+
+/*
+0xD3 - Illegal opcode
+
+0xD3, 0xDB, 0xDD, 0xE3, 0xE4, 0xEB, 0xEC, 0xED, 0xF4, 0xFC and 0xFD aren't valid instructions,
+executing any of them hard-locks the CPU until the system is powered off.
+
+Machine Cycles: 1
+*/
+func (m *instructions) illegal(cpu *CPU, opcode byte) uint32 {
+	cpu.lockup = &LockupError{PC: cpu.instructionPC, Opcode: opcode}
+	return 1
+}
+
 /*
 0xF3 - DI: Disable Interrupts
 
@@ -2275,6 +2246,7 @@ func (m *instructions) ei(cpu *CPU) uint32 {
 Machine Cycles: 2
 */
 func (m *instructions) inc_sp(cpu *CPU) uint32 {
+	cpu.incDec(cpu.SP)
 	cpu.SP += 1
 
 	return 2
@@ -2286,6 +2258,7 @@ func (m *instructions) inc_sp(cpu *CPU) uint32 {
 Machine Cycles: 2
 */
 func (m *instructions) dec_sp(cpu *CPU) uint32 {
+	cpu.incDec(cpu.SP)
 	cpu.SP -= 1
 
 	return 2
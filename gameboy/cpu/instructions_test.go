@@ -0,0 +1,97 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+func TestCBIndirectHL(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode byte
+		value  byte
+		want   byte
+		flags  uint16
+	}{
+		{"RRC (HL)", 0x0E, 0x01, 0x80, 0x10},
+		{"RRC (HL) zero", 0x0E, 0x00, 0x00, 0x80},
+		{"SRA (HL)", 0x2E, 0x81, 0xC0, 0x10},
+	}
+
+	for _, test := range tests {
+		s := scheduler.New()
+		bus := &flatMMU{scheduler: s, interrupts: mmu.InterruptControllerNew()}
+		c := New(bus, s)
+		c.Init()
+		c.SetState(State{PC: 0x0100, SP: 0xFFFE, BC: 0x1234, HL: 0xC000})
+
+		bus.memory[0x0100] = 0xCB
+		bus.memory[0x0101] = test.opcode
+		bus.memory[0xC000] = test.value
+
+		c.Cycle()
+
+		state := c.State()
+		if got := bus.memory[0xC000]; got != test.want {
+			t.Errorf("%s of %02X wrote %02X, want %02X", test.name, test.value, got, test.want)
+		}
+		if state.BC != 0x1234 {
+			t.Errorf("%s changed BC to %04X", test.name, state.BC)
+		}
+		if state.AF&0xFF != test.flags {
+			t.Errorf("%s flags = %02X, want %02X", test.name, state.AF&0xFF, test.flags)
+		}
+	}
+}
+
+func TestWordAccesses(t *testing.T) {
+	s := scheduler.New()
+	bus := &flatMMU{scheduler: s, interrupts: mmu.InterruptControllerNew()}
+	c := New(bus, s)
+	c.Init()
+	c.SetState(State{PC: 0x0100, SP: 0x1234})
+
+	// LD (0xFFFF), SP wraps around to 0x0000
+	bus.memory[0x0100] = 0x08
+	bus.memory[0x0101] = 0xFF
+	bus.memory[0x0102] = 0xFF
+
+	c.Cycle()
+
+	want := []busAccess{
+		{4, 0xFFFF, 0x34, true},
+		{5, 0x0000, 0x12, true},
+	}
+	for _, w := range want {
+		got := bus.accessesAt(w.cycle)
+		if len(got) != 1 || got[0] != w {
+			t.Errorf("cycle %d accesses = %+v, want %+v", w.cycle, got, w)
+		}
+	}
+}
+
+// after the HALT bug PC doesn't move past the illegal opcode
+func TestLockupAfterHaltBug(t *testing.T) {
+	s := scheduler.New()
+	m := mmu.NewMemoryManagementUnitImpl(s)
+	rom := make([]byte, 0x8000)
+	rom[0x0100] = 0x76 // HALT
+	rom[0x0101] = 0xD3
+	m.Init(rom)
+	c := New(m, s)
+	c.Init()
+
+	c.SetState(State{PC: 0x0100, SP: 0xFFFE})
+	m.WB(mmu.IE, 0x04)
+	m.Interrupts().Request(mmu.InterruptTimer)
+
+	c.Cycle()
+	c.Cycle()
+
+	want := LockupError{PC: 0x0101, Opcode: 0xD3}
+	if got := c.Lockup(); got == nil || *got != want {
+		t.Errorf("lockup = %v, want %v", got, &want)
+	}
+}
@@ -4,78 +4,79 @@ import (
 	"github.com/brunocroh/gameboy/gameboy/mmu"
 )
 
-const IE_ADDRESS = uint16(0xFFFF) // Interuptor Enabled memory location
-const IF_ADDRESS = uint16(0xFF0F) // Interuptor Flags memory location
-
 type interrupt struct {
 	IME  bool
 	EI   uint8
-	Halt uint8
-	mmu  mmu.MemoryManagementUnit
+	Halt bool
+
+	controller *mmu.InterruptController
 }
 
-func interruptNew(mmu mmu.MemoryManagementUnit) *interrupt {
+func interruptNew(controller *mmu.InterruptController) *interrupt {
 	return &interrupt{
-		mmu: mmu,
+		controller: controller,
 	}
 }
 
 func (m *interrupt) Init() {
 	m.IME = false
+	m.Halt = false
 }
 
-func (m *interrupt) handleInterrupt(cpu *CPU) uint32 {
-	enabled := m.mmu.RB(IE_ADDRESS)
-	requested := m.mmu.RB(IF_ADDRESS)
-
-	triggered := enabled & requested & 0x1F
+// pending returns the interrupts that are both requested and enabled.
+func (m *interrupt) pending() uint8 {
+	return m.controller.Pending()
+}
 
-	if triggered == 0 {
+func (m *interrupt) handleInterrupt(cpu *CPU) uint32 {
+	if m.pending() == 0 {
 		return 0
 	}
 
-	m.Halt = 0
+	m.Halt = false
 	if !m.IME {
 		return 0
 	}
 
-	var mask uint8
-	var vector uint16
-
-	switch {
-	case triggered&0x01 != 0:
-		mask = 0x01
-		vector = 0x40
-	case triggered&0x02 != 0:
-		mask = 0x02
-		vector = 0x48
-	case triggered&0x04 != 0:
-		mask = 0x04
-		vector = 0x50
-	case triggered&0x08 != 0:
-		mask = 0x08
-		vector = 0x58
-	case triggered&0x10 != 0:
-		mask = 0x10
-		vector = 0x60
-	}
-
 	m.IME = false
 
-	requested &^= mask
-	m.mmu.WB(IF_ADDRESS, requested)
+	// two internal machine cycles before the push
+	cpu.tick()
+	cpu.tick()
 
-	// push to stack
 	cpu.SP--
-	cpu.mmu.WB(cpu.SP, uint8(cpu.PC>>8))
+	cpu.write(cpu.SP, uint8(cpu.PC>>8))
+
+	// the interrupt is picked after the upper byte push, if it overwrote IE
+	// (SP was 0x0000) and nothing is pending anymore the dispatch is
+	// cancelled and it jumps to 0x0000
+	source, ok := m.controller.Highest()
+
 	cpu.SP--
-	cpu.mmu.WB(cpu.SP, uint8(cpu.PC))
+	cpu.write(cpu.SP, uint8(cpu.PC))
+
+	if !ok {
+		cpu.PC = 0x0000
+		return 5
+	}
 
-	cpu.PC = vector
+	m.controller.Acknowledge(source)
+	cpu.PC = interruptVector(source)
 
 	return 5
 }
 
+// interruptVector returns the handler address, 0x40 for VBlank up to 0x60
+// for the joypad.
+func interruptVector(source mmu.InterruptSource) uint16 {
+	vector := uint16(0x40)
+	for source > 1 {
+		source >>= 1
+		vector += 8
+	}
+	return vector
+}
+
 func (m *interrupt) updateIME() {
 	switch m.EI {
 	case 2:
@@ -0,0 +1,41 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+// pushing PC with SP at 0x0000 writes its upper byte to IE, the dispatch only
+// goes on if the interrupt is still enabled afterwards.
+func TestInterruptDispatchIEPush(t *testing.T) {
+	tests := []struct {
+		pc   uint16
+		want uint16
+	}{
+		{0x0434, 0x0050}, // IE keeps the timer bit
+		{0x1234, 0x0000}, // IE loses it, dispatch cancelled
+	}
+
+	for _, test := range tests {
+		s := scheduler.New()
+		m := mmu.NewMemoryManagementUnitImpl(s)
+		m.Init(make([]byte, 0x8000))
+		c := New(m, s)
+		c.Init()
+
+		c.SetState(State{PC: test.pc, SP: 0x0000, IME: true})
+		m.WB(mmu.IE, 0x04)
+		m.Interrupts().Request(mmu.InterruptTimer)
+
+		c.Cycle()
+
+		if c.PC != test.want {
+			t.Errorf("PC %04X: dispatched to %04X, want %04X", test.pc, c.PC, test.want)
+		}
+		if c.cycles != 5 {
+			t.Errorf("PC %04X: dispatch took %d machine cycles", test.pc, c.cycles)
+		}
+	}
+}
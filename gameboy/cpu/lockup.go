@@ -0,0 +1,20 @@
+package cpu
+
+import "fmt"
+
+// LockupError describes the CPU executing one of the illegal opcodes (0xD3,
+// 0xDB, 0xDD, 0xE3, 0xE4, 0xEB, 0xEC, 0xED, 0xF4, 0xFC, 0xFD), which hard-lock
+// the hardware until it is powered off.
+type LockupError struct {
+	PC     uint16
+	Opcode byte
+}
+
+func (e *LockupError) Error() string {
+	return fmt.Sprintf("cpu locked up by illegal opcode 0x%02X at 0x%04X", e.Opcode, e.PC)
+}
+
+// Lockup returns what locked the CPU up, or nil if it is running.
+func (m *CPU) Lockup() *LockupError {
+	return m.lockup
+}
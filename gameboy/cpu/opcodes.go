@@ -0,0 +1,334 @@
+package cpu
+
+import "fmt"
+
+// Opcode describes an instruction. It is the single source the dispatch
+// tables are built from, so the disassembler, tracers and tests consuming it
+// stay in sync with what is executed.
+//
+// Operands in the mnemonic use the placeholders n8, n16 (immediate data), a8,
+// a16 (addresses) and e8 (signed offset).
+type Opcode struct {
+	Mnemonic string
+	// Length in bytes, including the 0xCB prefix
+	Length uint8
+	// Machine cycles, for conditional instructions when the condition is false
+	Cycles uint8
+	// Machine cycles when the condition is true, 0 when not conditional
+	CyclesTaken uint8
+	// Affected flags in ZNHC order: the flag letter when set from the
+	// result, 0 or 1 when forced and - when untouched
+	Flags string
+}
+
+// Opcodes and CBOpcodes describe the base and 0xCB prefixed instructions.
+var (
+	Opcodes   [256]Opcode
+	CBOpcodes [256]Opcode
+)
+
+type handler func(cpu *CPU) uint32
+
+var (
+	dispatch   [256]handler
+	dispatchCB [256]handler
+)
+
+// operands of the 8-bit register encoding, index 6 is the (HL) indirection
+var r8Names = [8]string{"B", "C", "D", "E", "H", "L", "(HL)", "A"}
+
+const hlIndirect = 6
+
+var r16Names = [4]string{"BC", "DE", "HL", "SP"}
+var ccNames = [4]string{"NZ", "Z", "NC", "C"}
+
+func init() {
+	defineOpcodes()
+	defineCBOpcodes()
+}
+
+func define(code byte, o Opcode, exec handler) {
+	Opcodes[code] = o
+	dispatch[code] = exec
+}
+
+func defineCB(code byte, o Opcode, exec handler) {
+	CBOpcodes[code] = o
+	dispatchCB[code] = exec
+}
+
+// r8 returns the 8-bit register for its encoding index, (HL) isn't a register
+// and returns nil.
+func (m *CPU) r8(i int) *uint8 {
+	return m.r8s[i]
+}
+
+// r16 returns the high and low registers of BC, DE or HL.
+func (m *CPU) r16(i int) (*uint8, *uint8) {
+	return m.r8s[i*2], m.r8s[i*2+1]
+}
+
+func (m *CPU) condition(i int) bool {
+	switch i {
+	case 0:
+		return !m.register.getFlag(flagZ)
+	case 1:
+		return m.register.getFlag(flagZ)
+	case 2:
+		return !m.register.getFlag(flagC)
+	default:
+		return m.register.getFlag(flagC)
+	}
+}
+
+func defineOpcodes() {
+	define(0x00, Opcode{"NOP", 1, 1, 0, "----"}, func(c *CPU) uint32 { return c.ins.nop() })
+	define(0x08, Opcode{"LD (a16), SP", 3, 5, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_nn_sp(c) })
+	define(0x10, Opcode{"STOP n8", 2, 1, 0, "----"}, func(c *CPU) uint32 { return c.ins.stop(c) })
+	define(0x18, Opcode{"JR e8", 2, 3, 0, "----"}, func(c *CPU) uint32 { return c.ins.jr_e(c) })
+
+	define(0x20, Opcode{"JR NZ, e8", 2, 2, 3, "----"}, func(c *CPU) uint32 { return c.ins.jr_nz(c) })
+	define(0x28, Opcode{"JR Z, e8", 2, 2, 3, "----"}, func(c *CPU) uint32 { return c.ins.jr_z(c) })
+	define(0x30, Opcode{"JR NC, e8", 2, 2, 3, "----"}, func(c *CPU) uint32 { return c.ins.jr_nc(c) })
+	define(0x38, Opcode{"JR C, e8", 2, 2, 3, "----"}, func(c *CPU) uint32 { return c.ins.jr_c(c) })
+
+	// 16-bit loads and arithmetic
+	for i := 0; i < 3; i++ {
+		rr := i
+		name := r16Names[rr]
+		code := byte(rr << 4)
+
+		define(code|0x01, Opcode{"LD " + name + ", n16", 3, 3, 0, "----"}, func(c *CPU) uint32 {
+			hi, lo := c.r16(rr)
+			return c.ins.ld_rr_nn(c, hi, lo)
+		})
+		define(code|0x03, Opcode{"INC " + name, 1, 2, 0, "----"}, func(c *CPU) uint32 {
+			hi, lo := c.r16(rr)
+			return c.ins.inc_rr(c, hi, lo)
+		})
+		define(code|0x09, Opcode{"ADD HL, " + name, 1, 2, 0, "-0HC"}, func(c *CPU) uint32 {
+			hi, lo := c.r16(rr)
+			return c.ins.add_HL_rr(c, hi, lo)
+		})
+		define(code|0x0B, Opcode{"DEC " + name, 1, 2, 0, "----"}, func(c *CPU) uint32 {
+			hi, lo := c.r16(rr)
+			return c.ins.dec_rr(c, hi, lo)
+		})
+	}
+
+	define(0x31, Opcode{"LD SP, n16", 3, 3, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_SP_nn(c) })
+	define(0x33, Opcode{"INC SP", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.inc_sp(c) })
+	define(0x39, Opcode{"ADD HL, SP", 1, 2, 0, "-0HC"}, func(c *CPU) uint32 {
+		msb := uint8(c.SP >> 8)
+		lsb := uint8(c.SP)
+		return c.ins.add_HL_rr(c, &msb, &lsb)
+	})
+	define(0x3B, Opcode{"DEC SP", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.dec_sp(c) })
+
+	// indirect accumulator loads
+	define(0x02, Opcode{"LD (BC), A", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_BC_A(c) })
+	define(0x12, Opcode{"LD (DE), A", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_DE_A(c) })
+	define(0x22, Opcode{"LD (HL+), A", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_HLi_A(c) })
+	define(0x32, Opcode{"LD (HL-), A", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_HLd_A(c) })
+	define(0x0A, Opcode{"LD A, (BC)", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_A_BC(c) })
+	define(0x1A, Opcode{"LD A, (DE)", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_A_DE(c) })
+	define(0x2A, Opcode{"LD A, (HL+)", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_A_HLi(c) })
+	define(0x3A, Opcode{"LD A, (HL-)", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_A_HLd(c) })
+
+	// 8-bit INC, DEC and immediate loads
+	for i := 0; i < 8; i++ {
+		r := i
+		name := r8Names[r]
+		code := byte(r << 3)
+
+		if r == hlIndirect {
+			define(code|0x04, Opcode{"INC (HL)", 1, 3, 0, "Z0H-"}, func(c *CPU) uint32 { return c.ins.inc_HL(c) })
+			define(code|0x05, Opcode{"DEC (HL)", 1, 3, 0, "Z1H-"}, func(c *CPU) uint32 { return c.ins.dec_HL(c) })
+			define(code|0x06, Opcode{"LD (HL), n8", 2, 3, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_HL_n(c) })
+			continue
+		}
+
+		define(code|0x04, Opcode{"INC " + name, 1, 1, 0, "Z0H-"}, func(c *CPU) uint32 { return c.ins.inc_r(c, c.r8(r)) })
+		define(code|0x05, Opcode{"DEC " + name, 1, 1, 0, "Z1H-"}, func(c *CPU) uint32 { return c.ins.dec_r(c, c.r8(r)) })
+		define(code|0x06, Opcode{"LD " + name + ", n8", 2, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_r_n(c, c.r8(r)) })
+	}
+
+	define(0x07, Opcode{"RLCA", 1, 1, 0, "000C"}, func(c *CPU) uint32 { return c.ins.rlca(c) })
+	define(0x0F, Opcode{"RRCA", 1, 1, 0, "000C"}, func(c *CPU) uint32 { return c.ins.rrca(c) })
+	define(0x17, Opcode{"RLA", 1, 1, 0, "000C"}, func(c *CPU) uint32 { return c.ins.rla(c) })
+	define(0x1F, Opcode{"RRA", 1, 1, 0, "000C"}, func(c *CPU) uint32 { return c.ins.rra(c) })
+	define(0x27, Opcode{"DAA", 1, 1, 0, "Z-0C"}, func(c *CPU) uint32 { return c.ins.daa(c) })
+	define(0x2F, Opcode{"CPL", 1, 1, 0, "-11-"}, func(c *CPU) uint32 { return c.ins.cpl(c) })
+	define(0x37, Opcode{"SCF", 1, 1, 0, "-001"}, func(c *CPU) uint32 { return c.ins.scf(c) })
+	define(0x3F, Opcode{"CCF", 1, 1, 0, "-00C"}, func(c *CPU) uint32 { return c.ins.ccf(c) })
+
+	// 0x40 - 0x7F: LD r, r'
+	for i := 0; i < 64; i++ {
+		dst, src := i>>3, i&7
+		code := byte(0x40 | i)
+		mnemonic := "LD " + r8Names[dst] + ", " + r8Names[src]
+
+		switch {
+		case dst == hlIndirect && src == hlIndirect:
+			define(code, Opcode{"HALT", 1, 1, 0, "----"}, func(c *CPU) uint32 { return c.ins.halt(c) })
+		case dst == hlIndirect:
+			define(code, Opcode{mnemonic, 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_HL_r(c, c.r8(src)) })
+		case src == hlIndirect:
+			define(code, Opcode{mnemonic, 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_r_HL(c, c.r8(dst)) })
+		default:
+			define(code, Opcode{mnemonic, 1, 1, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_rr(c.r8(dst), c.r8(src)) })
+		}
+	}
+
+	// 0x80 - 0xBF: ALU A, r and their immediate version
+	alu := [8]struct {
+		name  string
+		flags string
+		r     func(m *instructions, cpu *CPU, r *uint8) uint32
+		hl    func(m *instructions, cpu *CPU) uint32
+		n     func(m *instructions, cpu *CPU) uint32
+	}{
+		{"ADD", "Z0HC", (*instructions).add_r, (*instructions).add_HL, (*instructions).add_n},
+		{"ADC", "Z0HC", (*instructions).adc_r, (*instructions).adc_HL, (*instructions).adc_n},
+		{"SUB", "Z1HC", (*instructions).sub_r, (*instructions).sub_HL, (*instructions).sub_n},
+		{"SBC", "Z1HC", (*instructions).sbc_r, (*instructions).sbc_HL, (*instructions).sbc_n},
+		{"AND", "Z010", (*instructions).and_r, (*instructions).and_HL, (*instructions).and_n},
+		{"XOR", "Z000", (*instructions).xor_r, (*instructions).xor_HL, (*instructions).xor_n},
+		{"OR", "Z000", (*instructions).or_a_r, (*instructions).or_a_HL, (*instructions).or_n},
+		{"CP", "Z1HC", (*instructions).cp_A_r, (*instructions).cp_HL, (*instructions).cp_n},
+	}
+
+	for i, op := range alu {
+		for j := 0; j < 8; j++ {
+			r := j
+			code := byte(0x80 | i<<3 | r)
+			mnemonic := op.name + " A, " + r8Names[r]
+
+			if r == hlIndirect {
+				define(code, Opcode{mnemonic, 1, 2, 0, op.flags}, func(c *CPU) uint32 { return op.hl(c.ins, c) })
+				continue
+			}
+
+			define(code, Opcode{mnemonic, 1, 1, 0, op.flags}, func(c *CPU) uint32 { return op.r(c.ins, c, c.r8(r)) })
+		}
+
+		define(byte(0xC6|i<<3), Opcode{op.name + " A, n8", 2, 2, 0, op.flags}, func(c *CPU) uint32 { return op.n(c.ins, c) })
+	}
+
+	// 0xC0 - 0xFF: flow, stack and high memory
+	for i := 0; i < 4; i++ {
+		cc := i
+		name := ccNames[cc]
+		code := byte(0xC0 | cc<<3)
+
+		define(code, Opcode{"RET " + name, 1, 2, 5, "----"}, func(c *CPU) uint32 { return c.ins.ret_cc(c, c.condition(cc)) })
+		define(code|0x02, Opcode{"JP " + name + ", a16", 3, 3, 4, "----"}, func(c *CPU) uint32 { return c.ins.jp_cc_nn(c, c.condition(cc)) })
+		define(code|0x04, Opcode{"CALL " + name + ", a16", 3, 3, 6, "----"}, func(c *CPU) uint32 { return c.ins.call_cc_nn(c, c.condition(cc)) })
+	}
+
+	for i := 0; i < 3; i++ {
+		rr := i
+		name := r16Names[rr]
+		code := byte(0xC0 | rr<<4)
+
+		define(code|0x01, Opcode{"POP " + name, 1, 3, 0, "----"}, func(c *CPU) uint32 {
+			hi, lo := c.r16(rr)
+			return c.ins.ld_pop_rr(c, hi, lo, false)
+		})
+		define(code|0x05, Opcode{"PUSH " + name, 1, 4, 0, "----"}, func(c *CPU) uint32 {
+			hi, lo := c.r16(rr)
+			return c.ins.ld_push_rr(c, hi, lo)
+		})
+	}
+
+	// the lower nibble of F is always zero
+	define(0xF1, Opcode{"POP AF", 1, 3, 0, "ZNHC"}, func(c *CPU) uint32 {
+		return c.ins.ld_pop_rr(c, &c.register.a, &c.register.f, true)
+	})
+	define(0xF5, Opcode{"PUSH AF", 1, 4, 0, "----"}, func(c *CPU) uint32 {
+		return c.ins.ld_push_rr(c, &c.register.a, &c.register.f)
+	})
+
+	for i := 0; i < 8; i++ {
+		vector := uint8(i << 3)
+		define(0xC7|vector, Opcode{fmt.Sprintf("RST $%02X", vector), 1, 4, 0, "----"}, func(c *CPU) uint32 { return c.ins.rst_n(c, vector) })
+	}
+
+	define(0xC3, Opcode{"JP a16", 3, 4, 0, "----"}, func(c *CPU) uint32 { return c.ins.jp_nn(c) })
+	define(0xC9, Opcode{"RET", 1, 4, 0, "----"}, func(c *CPU) uint32 { return c.ins.ret(c) })
+	define(0xCB, Opcode{"PREFIX", 1, 1, 0, "----"}, func(c *CPU) uint32 { return dispatchCB[c.fetchOpcode()](c) })
+	define(0xCD, Opcode{"CALL a16", 3, 6, 0, "----"}, func(c *CPU) uint32 { return c.ins.call_nn(c) })
+	define(0xD9, Opcode{"RETI", 1, 4, 0, "----"}, func(c *CPU) uint32 { return c.ins.reti(c) })
+	define(0xE0, Opcode{"LDH (a8), A", 2, 3, 0, "----"}, func(c *CPU) uint32 { return c.ins.ldh_n_A(c) })
+	define(0xE2, Opcode{"LDH (C), A", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ldh_C_A(c) })
+	define(0xE8, Opcode{"ADD SP, e8", 2, 4, 0, "00HC"}, func(c *CPU) uint32 { return c.ins.add_sp_e(c) })
+	define(0xE9, Opcode{"JP HL", 1, 1, 0, "----"}, func(c *CPU) uint32 { return c.ins.jp_HL(c) })
+	define(0xEA, Opcode{"LD (a16), A", 3, 4, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_nn_A(c) })
+	define(0xF0, Opcode{"LDH A, (a8)", 2, 3, 0, "----"}, func(c *CPU) uint32 { return c.ins.ldh_A_n(c) })
+	define(0xF2, Opcode{"LDH A, (C)", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ldh_A_C(c) })
+	define(0xF3, Opcode{"DI", 1, 1, 0, "----"}, func(c *CPU) uint32 { return c.ins.di(c) })
+	define(0xF8, Opcode{"LD HL, SP+e8", 2, 3, 0, "00HC"}, func(c *CPU) uint32 { return c.ins.ld_HL_spe(c) })
+	define(0xF9, Opcode{"LD SP, HL", 1, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_sp_HL(c) })
+	define(0xFA, Opcode{"LD A, (a16)", 3, 4, 0, "----"}, func(c *CPU) uint32 { return c.ins.ld_A_nn(c) })
+	define(0xFB, Opcode{"EI", 1, 1, 0, "----"}, func(c *CPU) uint32 { return c.ins.ei(c) })
+
+	for _, code := range []byte{0xD3, 0xDB, 0xDD, 0xE3, 0xE4, 0xEB, 0xEC, 0xED, 0xF4, 0xFC, 0xFD} {
+		define(code, Opcode{fmt.Sprintf("ILLEGAL_%02X", code), 1, 1, 0, "----"}, func(c *CPU) uint32 { return c.ins.illegal(c, code) })
+	}
+}
+
+func defineCBOpcodes() {
+	shifts := [8]struct {
+		name  string
+		flags string
+		r     func(m *instructions, cpu *CPU, r *uint8) uint32
+		hl    func(m *instructions, cpu *CPU) uint32
+	}{
+		{"RLC", "Z00C", (*instructions).rlc_r, (*instructions).rlc_HL},
+		{"RRC", "Z00C", (*instructions).rrc_r, (*instructions).rrc_HL},
+		{"RL", "Z00C", (*instructions).rl_r, (*instructions).rl_HL},
+		{"RR", "Z00C", (*instructions).rr_r, (*instructions).rr_HL},
+		{"SLA", "Z00C", (*instructions).sla_r, (*instructions).sla_HL},
+		{"SRA", "Z00C", (*instructions).sra_r, (*instructions).sra_HL},
+		{"SWAP", "Z000", (*instructions).swap_r, (*instructions).swap_HL},
+		{"SRL", "Z00C", (*instructions).srl_r, (*instructions).srl_HL},
+	}
+
+	for i := 0; i < 256; i++ {
+		code := byte(i)
+		y := uint8(i>>3) & 7
+		r := i & 7
+		name := r8Names[r]
+
+		switch i >> 6 {
+		case 0:
+			op := shifts[y]
+			if r == hlIndirect {
+				defineCB(code, Opcode{op.name + " (HL)", 2, 4, 0, op.flags}, func(c *CPU) uint32 { return op.hl(c.ins, c) })
+			} else {
+				defineCB(code, Opcode{op.name + " " + name, 2, 2, 0, op.flags}, func(c *CPU) uint32 { return op.r(c.ins, c, c.r8(r)) })
+			}
+		case 1:
+			mnemonic := fmt.Sprintf("BIT %d, %s", y, name)
+			if r == hlIndirect {
+				defineCB(code, Opcode{mnemonic, 2, 3, 0, "Z01-"}, func(c *CPU) uint32 { return c.ins.bit_u3_HL(c, y) })
+			} else {
+				defineCB(code, Opcode{mnemonic, 2, 2, 0, "Z01-"}, func(c *CPU) uint32 { return c.ins.bit_u3_r(c, y, c.r8(r)) })
+			}
+		case 2:
+			mnemonic := fmt.Sprintf("RES %d, %s", y, name)
+			if r == hlIndirect {
+				defineCB(code, Opcode{mnemonic, 2, 4, 0, "----"}, func(c *CPU) uint32 { return c.ins.res_u3_HL(c, y) })
+			} else {
+				defineCB(code, Opcode{mnemonic, 2, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.res_u3_r(c, y, c.r8(r)) })
+			}
+		case 3:
+			mnemonic := fmt.Sprintf("SET %d, %s", y, name)
+			if r == hlIndirect {
+				defineCB(code, Opcode{mnemonic, 2, 4, 0, "----"}, func(c *CPU) uint32 { return c.ins.set_b_HL(c, y) })
+			} else {
+				defineCB(code, Opcode{mnemonic, 2, 2, 0, "----"}, func(c *CPU) uint32 { return c.ins.set_b_r(c, y, c.r8(r)) })
+			}
+		}
+	}
+}
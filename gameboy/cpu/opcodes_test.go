@@ -0,0 +1,80 @@
+package cpu
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+// opcodeTakes reports if a conditional instruction branches with flags f,
+// which is either all clear or all set.
+func opcodeTakes(op Opcode, f uint8) bool {
+	_, operands, _ := strings.Cut(op.Mnemonic, " ")
+	condition, _, _ := strings.Cut(operands, ",")
+	return strings.HasPrefix(condition, "N") == (f == 0x00)
+}
+
+// branches reports if the instruction loads PC when it isn't conditional or
+// when the condition holds.
+func branches(op Opcode) bool {
+	for _, prefix := range []string{"JP", "JR", "CALL", "RET", "RST"} {
+		if strings.HasPrefix(op.Mnemonic, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestOpcodeMetadata executes every instruction and checks the machine cycles
+// it takes and how far PC moves against its Opcode description.
+func TestOpcodeMetadata(t *testing.T) {
+	s := scheduler.New()
+	bus := &flatMMU{scheduler: s, interrupts: mmu.InterruptControllerNew()}
+	c := New(bus, s)
+
+	run := func(code []byte, f uint8) (uint32, uint16) {
+		s.Init()
+		bus.reset()
+		c.Init()
+		// operands are zero, a relative jump lands right after itself
+		copy(bus.memory[0xC000:], code)
+		c.SetState(State{AF: uint16(f), SP: 0xD000, PC: 0xC000})
+
+		c.cycles = 0
+		c.execInstruction(c.fetchOpcode())
+		return c.cycles, c.PC - 0xC000
+	}
+
+	check := func(name string, code []byte, op Opcode) {
+		for _, f := range []uint8{0x00, 0xF0} {
+			cycles, advance := run(code, f)
+
+			taken := op.CyclesTaken != 0 && opcodeTakes(op, f)
+			want := op.Cycles
+			if taken {
+				want = op.CyclesTaken
+			}
+			if cycles != uint32(want) {
+				t.Errorf("%s %q with F=%02X took %d machine cycles, want %d", name, op.Mnemonic, f, cycles, want)
+			}
+
+			branch := branches(op) && (op.CyclesTaken == 0 || taken)
+			if (!branch || strings.HasPrefix(op.Mnemonic, "JR")) && advance != uint16(op.Length) {
+				t.Errorf("%s %q with F=%02X moved PC by %d, want %d", name, op.Mnemonic, f, advance, op.Length)
+			}
+		}
+	}
+
+	for opcode, op := range Opcodes {
+		if opcode == 0xCB || strings.HasPrefix(op.Mnemonic, "ILLEGAL") {
+			continue
+		}
+		check(fmt.Sprintf("%02X", opcode), []byte{byte(opcode)}, op)
+	}
+	for opcode, op := range CBOpcodes {
+		check(fmt.Sprintf("CB %02X", opcode), []byte{0xCB, byte(opcode)}, op)
+	}
+}
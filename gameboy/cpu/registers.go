@@ -1,7 +1,5 @@
 package cpu
 
-import "strings"
-
 type register struct {
 	a uint8
 	b uint8
@@ -14,6 +12,16 @@ type register struct {
 	f uint8
 }
 
+// flag is the bit of a flag in the F register
+type flag uint8
+
+const (
+	flagZ flag = 1 << 7 // Zero
+	flagN flag = 1 << 6 // Subtraction
+	flagH flag = 1 << 5 // Half carry
+	flagC flag = 1 << 4 // Carry
+)
+
 func registerNew() *register {
 	return &register{}
 }
@@ -29,37 +37,26 @@ func (m *register) Init() {
 	m.l = 0x4D
 }
 
-func (m *register) getFlag(flag string) bool {
-	switch strings.ToUpper(flag) {
-	case "Z":
-		return m.f&(1<<7) != 0
-	case "N":
-		return m.f&(1<<6) != 0
-	case "H":
-		return m.f&(1<<5) != 0
-	case "C":
-		return m.f&(1<<4) != 0
-	default:
-		return false
-	}
+func (m *register) getFlag(f flag) bool {
+	return m.f&uint8(f) != 0
 }
 
-func (m *register) setFlag(flag string, value bool) {
-	var newBit uint8
-	if value == true {
-		newBit = 1
+func (m *register) setFlag(f flag, value bool) {
+	if value {
+		m.f |= uint8(f)
 	} else {
-		newBit = 0
+		m.f &^= uint8(f)
 	}
+}
+
+// setFlags sets all four flags at once, the lower nibble of F is always zero.
+func (m *register) setFlags(z, n, h, c bool) {
+	m.f = flagBit(z, flagZ) | flagBit(n, flagN) | flagBit(h, flagH) | flagBit(c, flagC)
+}
 
-	switch flag {
-	case "Z":
-		m.f = (m.f & ^uint8(1<<7)) | (newBit << 7)
-	case "N":
-		m.f = (m.f & ^uint8(1<<6)) | (newBit << 6)
-	case "H":
-		m.f = (m.f & ^uint8(1<<5)) | (newBit << 5)
-	case "C":
-		m.f = (m.f & ^uint8(1<<4)) | (newBit << 4)
+func flagBit(value bool, f flag) uint8 {
+	if value {
+		return uint8(f)
 	}
+	return 0
 }
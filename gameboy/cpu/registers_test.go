@@ -0,0 +1,151 @@
+package cpu
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+// stringFlags is the former string keyed flag API, kept as the baseline of the
+// benchmarks.
+type stringFlags struct {
+	f uint8
+}
+
+func (m *stringFlags) getFlag(flag string) bool {
+	switch strings.ToUpper(flag) {
+	case "Z":
+		return m.f&(1<<7) != 0
+	case "N":
+		return m.f&(1<<6) != 0
+	case "H":
+		return m.f&(1<<5) != 0
+	case "C":
+		return m.f&(1<<4) != 0
+	default:
+		return false
+	}
+}
+
+func (m *stringFlags) setFlag(flag string, value bool) {
+	var newBit uint8
+	if value {
+		newBit = 1
+	}
+
+	switch flag {
+	case "Z":
+		m.f = (m.f & ^uint8(1<<7)) | (newBit << 7)
+	case "N":
+		m.f = (m.f & ^uint8(1<<6)) | (newBit << 6)
+	case "H":
+		m.f = (m.f & ^uint8(1<<5)) | (newBit << 5)
+	case "C":
+		m.f = (m.f & ^uint8(1<<4)) | (newBit << 4)
+	}
+}
+
+func newTestCPU() *CPU {
+	s := scheduler.New()
+	c := New(mmu.NewMemoryManagementUnitSimple(s), s)
+	c.Init()
+	return c
+}
+
+func TestFlags(t *testing.T) {
+	r := registerNew()
+
+	for _, f := range []flag{flagZ, flagN, flagH, flagC} {
+		r.f = 0
+		r.setFlag(f, true)
+		if r.f != uint8(f) || !r.getFlag(f) {
+			t.Errorf("setFlag(%08b, true) = %08b", f, r.f)
+		}
+
+		r.setFlag(f, false)
+		if r.f != 0 || r.getFlag(f) {
+			t.Errorf("setFlag(%08b, false) = %08b", f, r.f)
+		}
+	}
+
+	r.f = 0x0F
+	r.setFlags(true, false, true, false)
+	if r.f != 0xA0 {
+		t.Errorf("setFlags(true, false, true, false) = %02X, want A0", r.f)
+	}
+}
+
+func TestALUAllocations(t *testing.T) {
+	c := newTestCPU()
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		aluOps(c)
+	})
+
+	if allocs != 0 {
+		t.Errorf("ALU path allocates %v times per run", allocs)
+	}
+}
+
+func aluOps(c *CPU) {
+	b := &c.register.b
+	c.ins.add_r(c, b)
+	c.ins.adc_r(c, b)
+	c.ins.sub_r(c, b)
+	c.ins.sbc_r(c, b)
+	c.ins.and_r(c, b)
+	c.ins.xor_r(c, b)
+	c.ins.or_a_r(c, b)
+	c.ins.cp_A_r(c, b)
+	c.ins.inc_r(c, b)
+	c.ins.dec_r(c, b)
+	c.ins.daa(c)
+}
+
+func BenchmarkALU(b *testing.B) {
+	c := newTestCPU()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		c.register.b = uint8(i)
+		aluOps(c)
+	}
+}
+
+func BenchmarkFlagsString(b *testing.B) {
+	r := &stringFlags{}
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		v := i&1 != 0
+		r.setFlag("Z", v)
+		r.setFlag("N", false)
+		r.setFlag("H", !v)
+		r.setFlag("C", r.getFlag("Z"))
+	}
+}
+
+func BenchmarkFlagsTyped(b *testing.B) {
+	r := registerNew()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		v := i&1 != 0
+		r.setFlag(flagZ, v)
+		r.setFlag(flagN, false)
+		r.setFlag(flagH, !v)
+		r.setFlag(flagC, r.getFlag(flagZ))
+	}
+}
+
+func BenchmarkSetFlags(b *testing.B) {
+	r := registerNew()
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		v := i&1 != 0
+		r.setFlags(v, false, !v, r.getFlag(flagZ))
+	}
+}
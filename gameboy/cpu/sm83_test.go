@@ -0,0 +1,336 @@
+package cpu
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+// The SM83 single-step vectors (https://github.com/SingleStepTests/sm83) are
+// too big to be kept in the repository, the v1 json files are looked up in
+// testdata/sm83 or in the directory set by SM83_TESTS.
+const sm83TestsDir = "testdata/sm83"
+
+// opcodes the harness can't check against a flat RAM
+var sm83Skip = map[string]string{
+	"10": "STOP resets DIV and waits for the joypad",
+}
+
+type sm83State struct {
+	PC  uint16 `json:"pc"`
+	SP  uint16 `json:"sp"`
+	A   uint8  `json:"a"`
+	B   uint8  `json:"b"`
+	C   uint8  `json:"c"`
+	D   uint8  `json:"d"`
+	E   uint8  `json:"e"`
+	F   uint8  `json:"f"`
+	H   uint8  `json:"h"`
+	L   uint8  `json:"l"`
+	IME uint8  `json:"ime"`
+	IE  *uint8 `json:"ie"`
+	EI  *uint8 `json:"ei"`
+
+	RAM [][2]uint16 `json:"ram"`
+}
+
+// sm83Cycle is [address, data, pins], pins is "r-m" for a read, "-wm" for a
+// write and "---" for an internal cycle.
+type sm83Cycle []interface{}
+
+type sm83Test struct {
+	Name    string      `json:"name"`
+	Initial sm83State   `json:"initial"`
+	Final   sm83State   `json:"final"`
+	Cycles  []sm83Cycle `json:"cycles"`
+}
+
+type busAccess struct {
+	cycle   uint64
+	address uint16
+	value   uint8
+	write   bool
+}
+
+// flatMMU is 64KiB of RAM without any mapped registers, recording every
+// access with the machine cycle it happened in.
+type flatMMU struct {
+	scheduler  *scheduler.Scheduler
+	interrupts *mmu.InterruptController
+	memory     [0x10000]uint8
+	accesses   []busAccess
+}
+
+func (m *flatMMU) Dump(region mmu.Region, bank int) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *flatMMU) Init(rom []byte) {}
+
+func (m *flatMMU) ROMBank(address uint16) int {
+	return 0
+}
+
+func (m *flatMMU) IncDec(address uint16, read bool) {}
+
+func (m *flatMMU) SwitchSpeed() bool {
+	return false
+}
+
+func (m *flatMMU) DoubleSpeed() bool {
+	return false
+}
+
+func (m *flatMMU) SetButton(button mmu.Button, pressed bool) {}
+
+func (m *flatMMU) LoadBootROM(data []byte) {}
+
+func (m *flatMMU) SetSystemCounter(counter uint16) {}
+
+func (m *flatMMU) ResetDivider() {}
+
+func (m *flatMMU) SetSerialOutput(w io.Writer) {}
+
+func (m *flatMMU) Interrupts() *mmu.InterruptController {
+	return m.interrupts
+}
+
+func (m *flatMMU) reset() {
+	m.memory = [0x10000]uint8{}
+	m.accesses = m.accesses[:0]
+	m.interrupts.Init()
+}
+
+// accessesAt returns the accesses made in machine cycle c, the first cycle
+// of an instruction is 1.
+func (m *flatMMU) accessesAt(c uint64) []busAccess {
+	var found []busAccess
+	for _, access := range m.accesses {
+		if access.cycle == c {
+			found = append(found, access)
+		}
+	}
+	return found
+}
+
+func (m *flatMMU) RB(address uint16) uint8 {
+	value := m.memory[address]
+	m.accesses = append(m.accesses, busAccess{m.scheduler.Now() / 4, address, value, false})
+	return value
+}
+
+func (m *flatMMU) Peek(address uint16) uint8 {
+	return m.memory[address]
+}
+
+func (m *flatMMU) WB(address uint16, value uint8) {
+	m.memory[address] = value
+	m.accesses = append(m.accesses, busAccess{m.scheduler.Now() / 4, address, value, true})
+}
+
+func sm83Dir(t *testing.T) string {
+	dir := os.Getenv("SM83_TESTS")
+	if dir == "" {
+		dir = sm83TestsDir
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		t.Skipf("SM83 test vectors not found in %s", dir)
+	}
+
+	return dir
+}
+
+func TestSM83(t *testing.T) {
+	dir := sm83Dir(t)
+
+	var names []string
+	for opcode, op := range Opcodes {
+		if opcode != 0xCB && !strings.HasPrefix(op.Mnemonic, "ILLEGAL") {
+			names = append(names, fmt.Sprintf("%02x", opcode))
+		}
+	}
+	for opcode := range CBOpcodes {
+		names = append(names, fmt.Sprintf("cb %02x", opcode))
+	}
+
+	s := scheduler.New()
+	bus := &flatMMU{scheduler: s, interrupts: mmu.InterruptControllerNew()}
+	c := New(bus, s)
+
+	for _, name := range names {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			if reason, ok := sm83Skip[name]; ok {
+				t.Skip(reason)
+			}
+
+			tests := loadSM83(t, filepath.Join(dir, name+".json"))
+			for _, test := range tests {
+				s.Init()
+				bus.reset()
+				c.Init()
+
+				if err := runSM83(c, bus, test); err != nil {
+					t.Fatalf("%s: %s", test.Name, err)
+				}
+			}
+		})
+	}
+}
+
+func loadSM83(t *testing.T, path string) []sm83Test {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Skipf("%s not found", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests []sm83Test
+	if err := json.Unmarshal(data, &tests); err != nil {
+		t.Fatalf("%s: %s", path, err)
+	}
+
+	return tests
+}
+
+// runSM83 executes a single vector. The vectors overlap the fetch of the next
+// opcode with the last cycle of the instruction, so PC starts after the opcode
+// and the final cycle is the next fetch. Here the opcode is fetched again in
+// the first machine cycle and the next one isn't, the bus activity is the
+// same shifted by one cycle.
+func runSM83(c *CPU, bus *flatMMU, test sm83Test) error {
+	initial := test.Initial
+	for _, entry := range initial.RAM {
+		bus.memory[entry[0]] = uint8(entry[1])
+	}
+
+	c.SetState(State{
+		AF:  uint16(initial.A)<<8 | uint16(initial.F),
+		BC:  uint16(initial.B)<<8 | uint16(initial.C),
+		DE:  uint16(initial.D)<<8 | uint16(initial.E),
+		HL:  uint16(initial.H)<<8 | uint16(initial.L),
+		SP:  initial.SP,
+		PC:  initial.PC - 1,
+		IME: initial.IME != 0,
+	})
+	if initial.EI != nil && *initial.EI != 0 {
+		c.interrupt.EI = 1
+	}
+
+	c.cycles = 0
+	opcode := c.fetchOpcode()
+	c.execInstruction(opcode)
+	c.interrupt.updateIME()
+
+	if err := checkSM83State(c, bus, test.Final); err != nil {
+		return err
+	}
+
+	if int(c.cycles) != len(test.Cycles) {
+		return fmt.Errorf("took %d machine cycles, expected %d", c.cycles, len(test.Cycles))
+	}
+
+	// the last cycle is the fetch of the next opcode
+	for i, cycle := range test.Cycles[:len(test.Cycles)-1] {
+		if err := checkSM83Cycle(bus.accessesAt(uint64(i)+2), cycle); err != nil {
+			return fmt.Errorf("cycle %d: %s", i, err)
+		}
+	}
+
+	return nil
+}
+
+func checkSM83State(c *CPU, bus *flatMMU, final sm83State) error {
+	r := c.register
+	registers := []struct {
+		name      string
+		got, want uint16
+	}{
+		{"A", uint16(r.a), uint16(final.A)},
+		{"F", uint16(r.f), uint16(final.F)},
+		{"B", uint16(r.b), uint16(final.B)},
+		{"C", uint16(r.c), uint16(final.C)},
+		{"D", uint16(r.d), uint16(final.D)},
+		{"E", uint16(r.e), uint16(final.E)},
+		{"H", uint16(r.h), uint16(final.H)},
+		{"L", uint16(r.l), uint16(final.L)},
+		{"SP", c.SP, final.SP},
+		{"PC", c.PC + 1, final.PC},
+	}
+
+	for _, reg := range registers {
+		if reg.got != reg.want {
+			return fmt.Errorf("%s = %04X, expected %04X", reg.name, reg.got, reg.want)
+		}
+	}
+
+	// without a separate ei field the pending EI counts as enabled, the next
+	// instruction already started
+	ime := c.interrupt.IME
+	if final.EI != nil {
+		if pending := c.interrupt.EI != 0; pending != (*final.EI != 0) {
+			return fmt.Errorf("EI pending = %t, expected %d", pending, *final.EI)
+		}
+	} else {
+		ime = ime || c.interrupt.EI != 0
+	}
+	if ime != (final.IME != 0) {
+		return fmt.Errorf("IME = %t, expected %d", ime, final.IME)
+	}
+
+	for _, entry := range final.RAM {
+		if got := bus.memory[entry[0]]; got != uint8(entry[1]) {
+			return fmt.Errorf("[%04X] = %02X, expected %02X", entry[0], got, entry[1])
+		}
+	}
+
+	return nil
+}
+
+// checkSM83Cycle requires the bus to have exactly the access of the cycle,
+// or nothing for an internal one.
+func checkSM83Cycle(accesses []busAccess, cycle sm83Cycle) error {
+	if len(cycle) != 3 {
+		return checkIdleCycle(accesses)
+	}
+
+	pins, _ := cycle[2].(string)
+	address, _ := cycle[0].(float64)
+	value, _ := cycle[1].(float64)
+
+	if len(pins) != 3 || pins[0] != 'r' && pins[1] != 'w' {
+		return checkIdleCycle(accesses)
+	}
+
+	want := busAccess{address: uint16(address), value: uint8(value), write: pins[1] == 'w'}
+	if len(accesses) == 1 {
+		access := accesses[0]
+		access.cycle = 0
+		if access == want {
+			return nil
+		}
+	}
+
+	kind := "read"
+	if want.write {
+		kind = "write"
+	}
+	return fmt.Errorf("expected a single %s of %02X at %04X, bus had %v", kind, want.value, want.address, accesses)
+}
+
+func checkIdleCycle(accesses []busAccess) error {
+	if len(accesses) != 0 {
+		return fmt.Errorf("expected an internal cycle, bus had %v", accesses)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package cpu
+
+// State is a snapshot of the CPU registers and interrupt state, taken between
+// instructions.
+type State struct {
+	AF uint16
+	BC uint16
+	DE uint16
+	HL uint16
+	SP uint16
+	PC uint16
+
+	IME    bool
+	Halted bool
+	// EI was executed and IME is set after the next instruction
+	PendingEI bool
+}
+
+func (m *CPU) State() State {
+	r := m.register
+
+	return State{
+		AF:        uint16(r.a)<<8 | uint16(r.f),
+		BC:        uint16(r.b)<<8 | uint16(r.c),
+		DE:        uint16(r.d)<<8 | uint16(r.e),
+		HL:        uint16(r.h)<<8 | uint16(r.l),
+		SP:        m.SP,
+		PC:        m.PC,
+		IME:       m.interrupt.IME,
+		Halted:    m.interrupt.Halt,
+		PendingEI: m.interrupt.EI != 0,
+	}
+}
+
+// SetState restores a snapshot, the lower nibble of F is always zero.
+func (m *CPU) SetState(s State) {
+	r := m.register
+
+	r.a, r.f = uint8(s.AF>>8), uint8(s.AF)&0xF0
+	r.b, r.c = uint8(s.BC>>8), uint8(s.BC)
+	r.d, r.e = uint8(s.DE>>8), uint8(s.DE)
+	r.h, r.l = uint8(s.HL>>8), uint8(s.HL)
+	m.SP = s.SP
+	m.PC = s.PC
+
+	m.interrupt.IME = s.IME
+	m.interrupt.Halt = s.Halted
+	m.interrupt.EI = 0
+	if s.PendingEI {
+		m.interrupt.EI = 1
+	}
+}
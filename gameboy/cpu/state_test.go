@@ -0,0 +1,49 @@
+package cpu
+
+import (
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+func TestStateRoundTrip(t *testing.T) {
+	tests := []State{
+		{AF: 0x12F0, BC: 0x3456, DE: 0x789A, HL: 0xBCDE, SP: 0xFFFE, PC: 0x0100},
+		{AF: 0x01B0, SP: 0xDFF0, PC: 0xC000, IME: true},
+		{AF: 0xFF80, PC: 0x0150, Halted: true},
+		{AF: 0x0010, PC: 0x0200, PendingEI: true},
+		{AF: 0xAA50, PC: 0x4000, IME: true, Halted: true},
+	}
+
+	c := newTestCPU()
+	for _, want := range tests {
+		c.SetState(want)
+		if got := c.State(); got != want {
+			t.Errorf("State() = %+v after SetState, want %+v", got, want)
+		}
+	}
+}
+
+// F has no lower nibble, whatever is restored there is dropped
+func TestStateMasksF(t *testing.T) {
+	c := newTestCPU()
+	c.SetState(State{AF: 0x12FF})
+	if got := c.State().AF; got != 0x12F0 {
+		t.Errorf("AF = %04X after restoring 12FF, want 12F0", got)
+	}
+}
+
+// a restored pending EI enables IME after the next instruction
+func TestStatePendingEI(t *testing.T) {
+	s := scheduler.New()
+	bus := &flatMMU{scheduler: s, interrupts: mmu.InterruptControllerNew()}
+	c := New(bus, s)
+	c.Init()
+
+	c.SetState(State{PC: 0xC000, SP: 0xD000, PendingEI: true})
+	c.Cycle() // NOP
+	if state := c.State(); !state.IME || state.PendingEI {
+		t.Errorf("IME %t PendingEI %t after the next instruction, want true and false", state.IME, state.PendingEI)
+	}
+}
@@ -0,0 +1,89 @@
+package cpu
+
+import (
+	"fmt"
+	"io"
+)
+
+type TraceFormat int
+
+const (
+	// TraceDoctor is the Gameboy-Doctor line format:
+	// A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0100 PCMEM:00,C3,13,02
+	TraceDoctor TraceFormat = iota
+	// TraceBGB is a debugger style line with the disassembly, like BGB and
+	// SameBoy logs:
+	// 0100: 00        NOP              AF:01B0 BC:0013 DE:00D8 HL:014D SP:FFFE F:Z-HC IME:0 CY:0
+	TraceBGB
+	// TraceBinary writes 16 bytes records: A F B C D E H L, SP and PC in
+	// little endian, followed by the 4 bytes at PC.
+	TraceBinary
+)
+
+// ParseTraceFormat returns the format for its flag name: doctor, bgb or binary.
+func ParseTraceFormat(name string) (TraceFormat, error) {
+	switch name {
+	case "doctor":
+		return TraceDoctor, nil
+	case "bgb":
+		return TraceBGB, nil
+	case "binary":
+		return TraceBinary, nil
+	}
+	return 0, fmt.Errorf("unknown trace format %q", name)
+}
+
+// SetTrace writes the CPU state before each instruction to w, a nil writer
+// disables tracing.
+func (m *CPU) SetTrace(w io.Writer, format TraceFormat) {
+	m.traceOut = w
+	m.traceFormat = format
+}
+
+func (m *CPU) trace() {
+	r := m.register
+	pcmem := [4]byte{
+		m.mmu.Peek(m.PC),
+		m.mmu.Peek(m.PC + 1),
+		m.mmu.Peek(m.PC + 2),
+		m.mmu.Peek(m.PC + 3),
+	}
+
+	switch m.traceFormat {
+	case TraceDoctor:
+		fmt.Fprintf(m.traceOut, "A:%02X F:%02X B:%02X C:%02X D:%02X E:%02X H:%02X L:%02X SP:%04X PC:%04X PCMEM:%02X,%02X,%02X,%02X\n",
+			r.a, r.f, r.b, r.c, r.d, r.e, r.h, r.l, m.SP, m.PC,
+			pcmem[0], pcmem[1], pcmem[2], pcmem[3])
+	case TraceBGB:
+		text, length := Disassemble(m.mmu.Peek, m.PC)
+
+		var bytes string
+		for i := uint8(0); i < length; i++ {
+			bytes += fmt.Sprintf("%02X ", pcmem[i])
+		}
+
+		ime := 0
+		if m.interrupt.IME {
+			ime = 1
+		}
+
+		fmt.Fprintf(m.traceOut, "%04X: %-9s %-16s AF:%02X%02X BC:%02X%02X DE:%02X%02X HL:%02X%02X SP:%04X F:%s IME:%d CY:%d\n",
+			m.PC, bytes, text, r.a, r.f, r.b, r.c, r.d, r.e, r.h, r.l, m.SP, flagString(r.f), ime, m.scheduler.Now())
+	case TraceBinary:
+		m.traceOut.Write([]byte{
+			r.a, r.f, r.b, r.c, r.d, r.e, r.h, r.l,
+			byte(m.SP), byte(m.SP >> 8), byte(m.PC), byte(m.PC >> 8),
+			pcmem[0], pcmem[1], pcmem[2], pcmem[3],
+		})
+	}
+}
+
+func flagString(f uint8) string {
+	flags := []byte("----")
+	for i, name := range "ZNHC" {
+		if f&(0x80>>i) != 0 {
+			flags[i] = byte(name)
+		}
+	}
+	return string(flags)
+}
@@ -0,0 +1,57 @@
+package cpu
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+// traceTwo traces a NOP and the JP after it, from the post boot DMG state.
+func traceTwo(t *testing.T, format TraceFormat) []byte {
+	s := scheduler.New()
+	bus := &flatMMU{scheduler: s, interrupts: mmu.InterruptControllerNew()}
+	copy(bus.memory[0x0100:], []byte{0x00, 0xC3, 0x13, 0x02, 0xAA})
+	c := New(bus, s)
+	c.Init()
+	c.SetState(State{AF: 0x01B0, BC: 0x0013, DE: 0x00D8, HL: 0x014D, SP: 0xFFFE, PC: 0x0100})
+
+	var out bytes.Buffer
+	c.SetTrace(&out, format)
+	c.Cycle()
+	c.Cycle()
+
+	// PCMEM is peeked, only the instructions access the bus
+	if len(bus.accesses) != 4 {
+		t.Errorf("tracing made %d bus accesses, want the 4 of the instructions", len(bus.accesses))
+	}
+	return out.Bytes()
+}
+
+func TestTraceDoctor(t *testing.T) {
+	want := "A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0100 PCMEM:00,C3,13,02\n" +
+		"A:01 F:B0 B:00 C:13 D:00 E:D8 H:01 L:4D SP:FFFE PC:0101 PCMEM:C3,13,02,AA\n"
+	if got := string(traceTwo(t, TraceDoctor)); got != want {
+		t.Errorf("doctor trace\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTraceBGB(t *testing.T) {
+	want := "0100: 00        NOP              AF:01B0 BC:0013 DE:00D8 HL:014D SP:FFFE F:Z-HC IME:0 CY:0\n" +
+		"0101: C3 13 02  JP $0213         AF:01B0 BC:0013 DE:00D8 HL:014D SP:FFFE F:Z-HC IME:0 CY:4\n"
+	if got := string(traceTwo(t, TraceBGB)); got != want {
+		t.Errorf("bgb trace\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTraceBinary(t *testing.T) {
+	want := []byte{
+		// A F B C D E H L, SP and PC little endian, PCMEM
+		0x01, 0xB0, 0x00, 0x13, 0x00, 0xD8, 0x01, 0x4D, 0xFE, 0xFF, 0x00, 0x01, 0x00, 0xC3, 0x13, 0x02,
+		0x01, 0xB0, 0x00, 0x13, 0x00, 0xD8, 0x01, 0x4D, 0xFE, 0xFF, 0x01, 0x01, 0xC3, 0x13, 0x02, 0xAA,
+	}
+	if got := traceTwo(t, TraceBinary); !bytes.Equal(got, want) {
+		t.Errorf("binary trace\n% X\nwant\n% X", got, want)
+	}
+}
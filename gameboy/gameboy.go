@@ -2,15 +2,52 @@ package gameboy
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/brunocroh/gameboy/gameboy/cpu"
 	"github.com/brunocroh/gameboy/gameboy/mmu"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+// ClockSpeed is the DMG clock in T-cycles per second.
+const ClockSpeed = 4194304
+
+// CyclesPerFrame is the amount of dots (T-cycles) the LCD takes to draw a frame.
+const CyclesPerFrame = 70224
+
+// LockupPolicy decides what happens when the CPU executes an illegal opcode.
+type LockupPolicy int
+
+const (
+	// LockupHang behaves like the hardware, the CPU hangs forever while the
+	// rest of the system keeps running.
+	LockupHang LockupPolicy = iota
+	// LockupStop stops the emulation, Update and RunFrame return the
+	// *cpu.LockupError.
+	LockupStop
 )
 
 type GameBoy struct {
-	cpu *cpu.CPU
-	mmu mmu.MemoryManagementUnit
+	cpu       *cpu.CPU
+	mmu       mmu.MemoryManagementUnit
+	memory    *mmu.MemoryManagementUnitImpl
+	watcher   *mmu.Watcher
+	profiler  *mmu.Profiler
+	scheduler *scheduler.Scheduler
+
+	// timestamp where the current frame ends
+	frameEnd uint64
+
+	model    Model
+	bootROM  []byte
+	skipBoot bool
+	profile  bool
+	lyStub   bool
+
+	lockupPolicy   LockupPolicy
+	onLockup       func(err *cpu.LockupError)
+	lockupReported bool
 }
 
 func New() *GameBoy {
@@ -18,28 +55,255 @@ func New() *GameBoy {
 }
 
 func (m *GameBoy) Init(filePath string) {
-	m.mmu = mmu.NewMemoryManagementUnitSimple()
+	m.scheduler = scheduler.New()
+	m.scheduler.Init()
+	m.memory = mmu.NewMemoryManagementUnitImpl(m.scheduler)
+	m.memory.SetLYStub(m.lyStub)
+	m.memory.SetOAMBug(m.model != CGB)
+	m.watcher = mmu.NewWatcher(m.memory)
+	m.mmu = m.watcher
+	m.profiler = nil
+	if m.profile {
+		m.profiler = mmu.NewProfiler(m.watcher)
+		m.mmu = m.profiler
+	}
 	rom, err := LoadROM(filePath)
 
 	if err != nil {
 		fmt.Println("FAIL TO LOAD ROM", err)
 	}
 
+	if m.skipBoot {
+		m.mmu.LoadBootROM(nil)
+	} else if m.bootROM != nil {
+		m.mmu.LoadBootROM(m.bootROM)
+	} else {
+		m.mmu.LoadBootROM(mmu.BOOTROM[:])
+	}
+
 	m.mmu.Init(rom)
-	m.cpu = cpu.New(m.mmu)
+	m.cpu = cpu.New(m.mmu, m.scheduler)
 	m.cpu.Init()
+	m.watcher.SetPCSource(m.cpu.InstructionPC)
+	m.memory.SetPCSource(m.cpu.InstructionPC)
+	if m.profiler != nil {
+		m.cpu.SetFetchHook(m.profiler.Fetch)
+	}
+
+	if m.skipBoot {
+		m.applyPostBoot(rom)
+	} else {
+		// the boot ROM starts from zeroed registers
+		m.cpu.SetState(cpu.State{})
+	}
+
+	m.frameEnd = CyclesPerFrame
+	m.lockupReported = false
+}
+
+// SetModel chooses the console, DMG is the default. It is applied by Init.
+func (m *GameBoy) SetModel(model Model) {
+	m.model = model
+}
+
+// SetBootROM replaces the embedded DMG boot ROM, the image must be 256 bytes
+// (DMG, MGB, SGB) or 2304 bytes (CGB). It is applied by Init.
+func (m *GameBoy) SetBootROM(data []byte) error {
+	if len(data) != mmu.BOOTROM_SIZE && len(data) != mmu.CGB_BOOTROM_SIZE {
+		return fmt.Errorf("boot ROM must be %d or %d bytes, got %d", mmu.BOOTROM_SIZE, mmu.CGB_BOOTROM_SIZE, len(data))
+	}
+
+	m.bootROM = data
+	return nil
+}
+
+// SetSkipBoot starts the cartridge right away with the state the boot ROM of
+// the model leaves. It is applied by Init.
+func (m *GameBoy) SetSkipBoot(skip bool) {
+	m.skipBoot = skip
+}
+
+// SetLYStub makes LY always read 0x90 like gameboy-doctor expects, the LCD
+// keeps its timing otherwise. It is applied by Init.
+func (m *GameBoy) SetLYStub(enabled bool) {
+	m.lyStub = enabled
+}
+
+// SetProfiling counts the memory accesses from power on, see Profiler. It is
+// applied by Init.
+func (m *GameBoy) SetProfiling(enabled bool) {
+	m.profile = enabled
+}
+
+// Profiler returns the memory access counters, nil if profiling is disabled.
+func (m *GameBoy) Profiler() *mmu.Profiler {
+	return m.profiler
+}
+
+// SetLockupPolicy chooses how an illegal opcode is handled, LockupHang is the
+// default.
+func (m *GameBoy) SetLockupPolicy(policy LockupPolicy) {
+	m.lockupPolicy = policy
+}
+
+// OnLockup registers a callback fired once when the CPU locks up, whatever
+// the policy is.
+func (m *GameBoy) OnLockup(fn func(err *cpu.LockupError)) {
+	m.onLockup = fn
+}
+
+// SetTrace writes the CPU state before each instruction to w, a nil writer
+// disables tracing.
+func (m *GameBoy) SetTrace(w io.Writer, format cpu.TraceFormat) {
+	m.cpu.SetTrace(w, format)
+}
+
+// SetOpcodeHook calls hook right before every execution of the opcode, nil
+// removes it.
+func (m *GameBoy) SetOpcodeHook(opcode byte, hook cpu.OpcodeHook) {
+	m.cpu.SetOpcodeHook(opcode, hook)
+}
+
+// OnBreakpoint calls hook when the LD B,B software breakpoint executes.
+func (m *GameBoy) OnBreakpoint(hook cpu.OpcodeHook) {
+	m.cpu.OnBreakpoint(hook)
+}
+
+// OnDebugMessage calls fn with the LD D,D debug messages of the ROM.
+func (m *GameBoy) OnDebugMessage(fn func(message string)) {
+	m.cpu.OnDebugMessage(fn)
+}
+
+// Watch calls callback on the accesses of the given kinds from start to end
+// (inclusive), returning the watchpoint id. A pausing watchpoint makes Update
+// and RunFrame return the *mmu.WatchEvent after the instruction, calling them
+// again resumes.
+func (m *GameBoy) Watch(start, end uint16, kind mmu.WatchKind, pause bool, callback func(e mmu.WatchEvent)) int {
+	return m.watcher.Watch(start, end, kind, pause, callback)
+}
+
+func (m *GameBoy) Unwatch(id int) {
+	m.watcher.Unwatch(id)
+}
+
+// OnBlockedAccess calls fn on every VRAM or OAM access ignored because the
+// PPU was using the memory, writing VRAM at the wrong time is easy to miss.
+func (m *GameBoy) OnBlockedAccess(fn func(e mmu.BlockedAccess)) {
+	m.memory.OnBlockedAccess(fn)
+}
+
+// SetSerialOutput writes every byte sent through the link port to w.
+func (m *GameBoy) SetSerialOutput(w io.Writer) {
+	m.mmu.SetSerialOutput(w)
+}
+
+// Peek reads memory the same way the CPU does, without spending any time,
+// triggering watchpoints or any other side effect.
+func (m *GameBoy) Peek(address uint16) byte {
+	return m.memory.Peek(address)
+}
+
+// Cycles returns the T-cycles elapsed since Init.
+func (m *GameBoy) Cycles() uint64 {
+	return m.scheduler.Now()
+}
+
+// CPUState returns a snapshot of the CPU registers.
+func (m *GameBoy) CPUState() cpu.State {
+	return m.cpu.State()
+}
+
+func (m *GameBoy) SetCPUState(state cpu.State) {
+	m.cpu.SetState(state)
+}
+
+// Update executes a single instruction.
+func (m *GameBoy) Update() error {
+	return m.step()
+}
+
+func (m *GameBoy) step() error {
+	lockup := m.cpu.Lockup()
+	if lockup == nil || m.lockupPolicy == LockupHang {
+		m.cpu.Cycle()
+		lockup = m.cpu.Lockup()
+	}
+
+	if event, ok := m.watcher.TakePause(); ok {
+		return event
+	}
+
+	if lockup == nil {
+		return nil
+	}
+
+	if !m.lockupReported {
+		m.lockupReported = true
+		if m.onLockup != nil {
+			m.onLockup(lockup)
+		}
+	}
+
+	if m.lockupPolicy == LockupStop {
+		return lockup
+	}
+
+	return nil
 }
 
-func (m *GameBoy) Update() {
-	m.cpu.Cycle()
+// SetButton presses or releases a joypad key, pressing one wakes the CPU up
+// from STOP.
+func (m *GameBoy) SetButton(button mmu.Button, pressed bool) {
+	m.mmu.SetButton(button, pressed)
+}
+
+// Stopped reports if the CPU executed STOP and waits for a joypad key, no
+// time passes until then.
+func (m *GameBoy) Stopped() bool {
+	return m.cpu.Stopped()
+}
+
+// RunFrame executes instructions until a whole frame (70224 dots) elapsed. An
+// instruction crossing the frame boundary is accounted to the next frame.
+//
+// While the CPU is stopped no time passes, so it returns right away.
+func (m *GameBoy) RunFrame() error {
+	for m.scheduler.Now() < m.frameEnd {
+		if err := m.step(); err != nil {
+			return err
+		}
+
+		if m.cpu.Stopped() {
+			return nil
+		}
+	}
+
+	m.frameEnd += m.frameCycles()
+	return nil
+}
+
+// frameCycles returns how many CPU cycles a frame takes, in CGB double speed
+// mode the CPU runs twice as many cycles for the same amount of dots.
+func (m *GameBoy) frameCycles() uint64 {
+	if m.mmu.DoubleSpeed() {
+		return CyclesPerFrame * 2
+	}
+	return CyclesPerFrame
 }
 
 func (m *GameBoy) Debug() {
+	hram, _ := m.mmu.Dump(mmu.RegionHRAM, 0)
+
 	fmt.Println("======== DEBUG =========")
-	fmt.Println(m.mmu.Dump())
+	fmt.Print(mmu.HexDump(hram, mmu.HRAM_START))
 	fmt.Println("========================")
 }
 
+// Dump copies a memory region, bank selects the ROM and cartridge RAM banks.
+func (m *GameBoy) Dump(region mmu.Region, bank int) ([]byte, error) {
+	return m.mmu.Dump(region, bank)
+}
+
 func LoadROM(filePath string) ([]byte, error) {
 	data, err := os.ReadFile(filePath)
 
@@ -0,0 +1,133 @@
+package gameboy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/mmu"
+)
+
+// testROM returns a 32KiB cartridge running code from the entry point.
+func testROM(code ...byte) []byte {
+	rom := make([]byte, 0x8000)
+	copy(rom[0x0100:], code)
+	return rom
+}
+
+// writeROM stores rom in a temporary file for Init.
+func writeROM(t *testing.T, rom []byte) string {
+	path := filepath.Join(t.TempDir(), "test.gb")
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeStopROM writes a ROM executing STOP and then storing 0x42 to 0xC000.
+func writeStopROM(t *testing.T) string {
+	return writeROM(t, testROM(
+		0x10, 0x00, // STOP
+		0x3E, 0x42, // LD A, 0x42
+		0xEA, 0x00, 0xC0, // LD (0xC000), A
+		0x18, 0xFE, // JR -2
+	))
+}
+
+func TestStop(t *testing.T) {
+	rom := writeStopROM(t)
+
+	// no key is ever pressed, the runner must give up instead of spinning
+	if result := runTestROM(rom); result.passed || !strings.Contains(result.detail, "stopped") {
+		t.Errorf("test ROM runner = %+v, want it stopped", result)
+	}
+
+	gb := New()
+	gb.SetSkipBoot(true)
+	gb.Init(rom)
+
+	if err := gb.Update(); err != nil || !gb.Stopped() {
+		t.Fatalf("STOP didn't stop the CPU, %v", err)
+	}
+	if got := gb.Peek(mmu.DIV); got != 0x00 {
+		t.Errorf("DIV read %02X after STOP, want it reset", got)
+	}
+
+	cycles := gb.Cycles()
+	if err := gb.RunFrame(); err != nil || gb.Cycles() != cycles {
+		t.Errorf("time passed while stopped, %d cycles, %v", gb.Cycles()-cycles, err)
+	}
+
+	// P1 selects both groups after the boot ROM
+	gb.SetButton(mmu.ButtonStart, true)
+	if err := gb.RunFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if gb.Stopped() {
+		t.Fatal("pressing Start didn't wake the CPU up")
+	}
+	if got := gb.Peek(0xC000); got != 0x42 {
+		t.Errorf("0xC000 = %02X after waking up, want 42", got)
+	}
+	if gb.Peek(mmu.IF)&byte(mmu.InterruptJoypad) == 0 {
+		t.Error("pressing Start didn't request the joypad interrupt")
+	}
+	if got := gb.Peek(mmu.P1); got != 0xC7 {
+		t.Errorf("P1 read %02X with Start pressed, want C7", got)
+	}
+}
+
+func TestRunFrame(t *testing.T) {
+	gb := New()
+	gb.SetSkipBoot(true)
+	// a 4 cycle NOP and then 12 cycle jumps, the frame boundary always falls
+	// in the middle of a jump
+	gb.Init(writeROM(t, testROM(
+		0x00,       // NOP
+		0x18, 0xFE, // JR -2
+	)))
+
+	for frame := uint64(1); frame <= 10; frame++ {
+		if err := gb.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+
+		// the jump crossing the boundary finishes 4 cycles later, that time
+		// is taken from the next frame instead of drifting
+		if want := frame*CyclesPerFrame + 4; gb.Cycles() != want {
+			t.Fatalf("frame %d ended at cycle %d, want %d", frame, gb.Cycles(), want)
+		}
+	}
+}
+
+func TestRunFrameDoubleSpeed(t *testing.T) {
+	rom := testROM(
+		0x3E, 0x01, // LD A, 0x01
+		0xE0, 0x4D, // LDH (KEY1), A
+		0x10, 0x00, // STOP
+		0x18, 0xFE, // JR -2
+	)
+	// CGB flag in the header, KEY1 only exists on CGB cartridges
+	rom[0x143] = 0x80
+
+	gb := New()
+	gb.SetModel(CGB)
+	gb.SetSkipBoot(true)
+	gb.Init(writeROM(t, rom))
+
+	// the speed switch happens in the first frame
+	for i := 0; i < 2; i++ {
+		if err := gb.RunFrame(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	start := gb.Cycles()
+	if err := gb.RunFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if got := gb.Cycles() - start; got != 2*CyclesPerFrame {
+		t.Errorf("double speed frame took %d cycles, want %d", got, 2*CyclesPerFrame)
+	}
+}
@@ -1,13 +1,116 @@
 package mbc
 
-type MemoryBankController struct {
+const (
+	ROM_BANK_SIZE = 0x4000
+	RAM_BANK_SIZE = 0x2000
+
+	CARTRIDGE_TYPE = 0x0147
+	RAM_SIZE       = 0x0149
+)
+
+// MemoryBankController maps the cartridge, ROM at 0x0000-0x7FFF and external
+// RAM at 0xA000-0xBFFF. Writes to the ROM area set its bank registers.
+type MemoryBankController interface {
+	RB(address uint16) byte
+	WB(address uint16, value byte)
+	// ROMBank returns the ROM bank mapped at an address of 0x0000-0x7FFF.
+	ROMBank(address uint16) int
+	// OpenBus reports if nothing answers a read of address, like disabled or
+	// missing RAM, the bus keeps the last value on it. RB reads 0xFF then.
+	OpenBus(address uint16) bool
+	// ROM and RAM return the whole cartridge memories, for debugging
+	ROM() []byte
+	RAM() []byte
+}
+
+// New returns the controller described by the cartridge type in the header,
+// unknown types are mapped as a plain 32KiB ROM.
+func New(rom []byte) MemoryBankController {
+	c := cartridgeNew(rom)
+
+	switch c.header(CARTRIDGE_TYPE) {
+	case 0x01, 0x02, 0x03:
+		return mbc1New(c)
+	case 0x05, 0x06:
+		return mbc2New(c)
+	case 0x0F, 0x10, 0x11, 0x12, 0x13:
+		return mbc3New(c)
+	case 0x19, 0x1A, 0x1B, 0x1C, 0x1D, 0x1E:
+		return mbc5New(c)
+	default:
+		return mbc0New(c)
+	}
+}
+
+// cartridge holds the ROM and the external RAM sized from the header.
+type cartridge struct {
+	rom []byte
+	ram []byte
+}
+
+func cartridgeNew(rom []byte) *cartridge {
+	c := &cartridge{rom: rom}
+
+	switch c.header(RAM_SIZE) {
+	case 0x01:
+		c.ram = make([]byte, 0x800)
+	case 0x02:
+		c.ram = make([]byte, 0x2000)
+	case 0x03:
+		c.ram = make([]byte, 0x8000)
+	case 0x04:
+		c.ram = make([]byte, 0x20000)
+	case 0x05:
+		c.ram = make([]byte, 0x10000)
+	}
+
+	return c
+}
+
+func (m *cartridge) ROM() []byte {
+	return m.rom
+}
+
+func (m *cartridge) RAM() []byte {
+	return m.ram
 }
 
-func New() *MemoryBankController {
+func (m *cartridge) header(address int) byte {
+	if address >= len(m.rom) {
+		return 0
+	}
+	return m.rom[address]
+}
+
+// bank wraps a bank number around the size of the ROM.
+func (m *cartridge) bank(bank int) int {
+	banks := len(m.rom) / ROM_BANK_SIZE
+	if banks == 0 {
+		return 0
+	}
+	return bank % banks
+}
+
+// readROM reads from a 16KiB bank, bank numbers past the end of the ROM wrap
+// around like the unconnected address lines do.
+func (m *cartridge) readROM(bank int, address uint16) byte {
+	if len(m.rom) == 0 {
+		return 0xFF
+	}
+	return m.rom[(bank*ROM_BANK_SIZE+int(address&0x3FFF))%len(m.rom)]
+}
 
-	return &MemoryBankController{}
+// readRAM reads from an 8KiB bank, there is nothing to read without RAM.
+func (m *cartridge) readRAM(bank int, address uint16) byte {
+	if len(m.ram) == 0 {
+		return 0xFF
+	}
+	return m.ram[(bank*RAM_BANK_SIZE+int(address&0x1FFF))%len(m.ram)]
 }
 
-func (m *MemoryBankController) RB(address uint16) byte {
-	return 0xFF
+func (m *cartridge) writeRAM(bank int, address uint16, value byte) {
+	if len(m.ram) == 0 {
+		return
+	}
+	m.ram[(bank*RAM_BANK_SIZE+int(address&0x1FFF))%len(m.ram)] = value
 }
@@ -1,4 +1,39 @@
 package mbc
 
+// MBC0 is a cartridge without controller, 32KiB of ROM and optionally 8KiB
+// of RAM.
 type MBC0 struct {
+	*cartridge
+}
+
+func mbc0New(c *cartridge) *MBC0 {
+	return &MBC0{cartridge: c}
+}
+
+func (m *MBC0) ROMBank(address uint16) int {
+	if address < 0x4000 {
+		return 0
+	}
+	return m.bank(1)
+}
+
+func (m *MBC0) OpenBus(address uint16) bool {
+	return address >= 0xA000 && len(m.ram) == 0
+}
+
+func (m *MBC0) RB(address uint16) byte {
+	switch {
+	case address < 0x4000:
+		return m.readROM(0, address)
+	case address < 0x8000:
+		return m.readROM(1, address)
+	default:
+		return m.readRAM(0, address)
+	}
+}
+
+func (m *MBC0) WB(address uint16, value byte) {
+	if address >= 0xA000 {
+		m.writeRAM(0, address, value)
+	}
 }
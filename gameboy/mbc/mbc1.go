@@ -1,4 +1,76 @@
 package mbc
 
+// MBC1 switches up to 2MiB of ROM and 32KiB of RAM. The 2-bit register is
+// either the upper bits of the ROM bank or, in mode 1, the RAM bank and the
+// bank mapped at 0x0000-0x3FFF.
 type MBC1 struct {
+	*cartridge
+
+	ramEnabled bool
+	bank1      int // 5 bits, 0 is mapped as 1
+	bank2      int // 2 bits
+	mode       byte
+}
+
+func mbc1New(c *cartridge) *MBC1 {
+	return &MBC1{cartridge: c, bank1: 1}
+}
+
+func (m *MBC1) ROMBank(address uint16) int {
+	switch {
+	case address >= 0x4000:
+		return m.bank(m.bank2<<5 | m.bank1)
+	case m.mode == 1:
+		return m.bank(m.bank2 << 5)
+	default:
+		return 0
+	}
+}
+
+func (m *MBC1) OpenBus(address uint16) bool {
+	return address >= 0xA000 && (!m.ramEnabled || len(m.ram) == 0)
+}
+
+func (m *MBC1) RB(address uint16) byte {
+	switch {
+	case address < 0x4000:
+		if m.mode == 1 {
+			return m.readROM(m.bank2<<5, address)
+		}
+		return m.readROM(0, address)
+	case address < 0x8000:
+		return m.readROM(m.bank2<<5|m.bank1, address)
+	default:
+		if !m.ramEnabled {
+			return 0xFF
+		}
+		return m.readRAM(m.ramBank(), address)
+	}
+}
+
+func (m *MBC1) WB(address uint16, value byte) {
+	switch {
+	case address < 0x2000:
+		m.ramEnabled = value&0x0F == 0x0A
+	case address < 0x4000:
+		m.bank1 = int(value & 0x1F)
+		if m.bank1 == 0 {
+			m.bank1 = 1
+		}
+	case address < 0x6000:
+		m.bank2 = int(value & 0x03)
+	case address < 0x8000:
+		m.mode = value & 0x01
+	default:
+		if m.ramEnabled {
+			m.writeRAM(m.ramBank(), address, value)
+		}
+	}
+}
+
+func (m *MBC1) ramBank() int {
+	if m.mode == 1 {
+		return m.bank2
+	}
+	return 0
 }
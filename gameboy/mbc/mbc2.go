@@ -1,4 +1,64 @@
 package mbc
 
+// MBC2 switches up to 256KiB of ROM and has 512 half-bytes of RAM built in,
+// mirrored through 0xA000-0xBFFF. Bit 8 of the address chooses between the RAM
+// enable and the ROM bank register.
 type MBC2 struct {
+	*cartridge
+
+	ramEnabled bool
+	romBank    int
+	ram        [0x200]byte
+}
+
+func mbc2New(c *cartridge) *MBC2 {
+	return &MBC2{cartridge: c, romBank: 1}
+}
+
+func (m *MBC2) RAM() []byte {
+	return m.ram[:]
+}
+
+func (m *MBC2) ROMBank(address uint16) int {
+	if address < 0x4000 {
+		return 0
+	}
+	return m.bank(m.romBank)
+}
+
+func (m *MBC2) OpenBus(address uint16) bool {
+	return address >= 0xA000 && (!m.ramEnabled)
+}
+
+func (m *MBC2) RB(address uint16) byte {
+	switch {
+	case address < 0x4000:
+		return m.readROM(0, address)
+	case address < 0x8000:
+		return m.readROM(m.romBank, address)
+	default:
+		if !m.ramEnabled {
+			return 0xFF
+		}
+		// only the lower nibble exists
+		return 0xF0 | m.ram[address&0x1FF]
+	}
+}
+
+func (m *MBC2) WB(address uint16, value byte) {
+	switch {
+	case address < 0x4000:
+		if address&0x100 == 0 {
+			m.ramEnabled = value&0x0F == 0x0A
+			return
+		}
+		m.romBank = int(value & 0x0F)
+		if m.romBank == 0 {
+			m.romBank = 1
+		}
+	case address >= 0xA000:
+		if m.ramEnabled {
+			m.ram[address&0x1FF] = value & 0x0F
+		}
+	}
 }
@@ -1,4 +1,72 @@
 package mbc
 
+// MBC3 switches up to 2MiB of ROM and 32KiB of RAM. The RAM bank register
+// also selects the real time clock registers (0x08-0x0C), they are kept as
+// plain values, the clock doesn't tick.
 type MBC3 struct {
+	*cartridge
+
+	ramEnabled bool
+	romBank    int
+	ramBank    int
+	rtc        [5]byte
+}
+
+func mbc3New(c *cartridge) *MBC3 {
+	return &MBC3{cartridge: c, romBank: 1}
+}
+
+func (m *MBC3) ROMBank(address uint16) int {
+	if address < 0x4000 {
+		return 0
+	}
+	return m.bank(m.romBank)
+}
+
+func (m *MBC3) OpenBus(address uint16) bool {
+	return address >= 0xA000 && (!m.ramEnabled || m.ramBank < 0x08 && len(m.ram) == 0)
+}
+
+func (m *MBC3) RB(address uint16) byte {
+	switch {
+	case address < 0x4000:
+		return m.readROM(0, address)
+	case address < 0x8000:
+		return m.readROM(m.romBank, address)
+	default:
+		if !m.ramEnabled {
+			return 0xFF
+		}
+		if m.ramBank >= 0x08 {
+			return m.rtc[m.ramBank-0x08]
+		}
+		return m.readRAM(m.ramBank, address)
+	}
+}
+
+func (m *MBC3) WB(address uint16, value byte) {
+	switch {
+	case address < 0x2000:
+		m.ramEnabled = value&0x0F == 0x0A
+	case address < 0x4000:
+		m.romBank = int(value & 0x7F)
+		if m.romBank == 0 {
+			m.romBank = 1
+		}
+	case address < 0x6000:
+		if value <= 0x03 || value >= 0x08 && value <= 0x0C {
+			m.ramBank = int(value)
+		}
+	case address < 0x8000:
+		// latching the clock, nothing to do while it doesn't tick
+	default:
+		if !m.ramEnabled {
+			return
+		}
+		if m.ramBank >= 0x08 {
+			m.rtc[m.ramBank-0x08] = value
+			return
+		}
+		m.writeRAM(m.ramBank, address, value)
+	}
 }
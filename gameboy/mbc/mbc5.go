@@ -1,4 +1,59 @@
 package mbc
 
+// MBC5 switches up to 8MiB of ROM with a 9-bit bank number, where bank 0 can
+// be mapped at 0x4000 too, and 128KiB of RAM.
 type MBC5 struct {
+	*cartridge
+
+	ramEnabled bool
+	romBank    int
+	ramBank    int
+}
+
+func mbc5New(c *cartridge) *MBC5 {
+	return &MBC5{cartridge: c, romBank: 1}
+}
+
+func (m *MBC5) ROMBank(address uint16) int {
+	if address < 0x4000 {
+		return 0
+	}
+	return m.bank(m.romBank)
+}
+
+func (m *MBC5) OpenBus(address uint16) bool {
+	return address >= 0xA000 && (!m.ramEnabled || len(m.ram) == 0)
+}
+
+func (m *MBC5) RB(address uint16) byte {
+	switch {
+	case address < 0x4000:
+		return m.readROM(0, address)
+	case address < 0x8000:
+		return m.readROM(m.romBank, address)
+	default:
+		if !m.ramEnabled {
+			return 0xFF
+		}
+		return m.readRAM(m.ramBank, address)
+	}
+}
+
+func (m *MBC5) WB(address uint16, value byte) {
+	switch {
+	case address < 0x2000:
+		m.ramEnabled = value&0x0F == 0x0A
+	case address < 0x3000:
+		m.romBank = m.romBank&0x100 | int(value)
+	case address < 0x4000:
+		m.romBank = m.romBank&0xFF | int(value&0x01)<<8
+	case address < 0x6000:
+		m.ramBank = int(value & 0x0F)
+	case address < 0x8000:
+		// unused
+	default:
+		if m.ramEnabled {
+			m.writeRAM(m.ramBank, address, value)
+		}
+	}
 }
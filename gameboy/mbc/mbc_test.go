@@ -0,0 +1,144 @@
+package mbc
+
+import "testing"
+
+// testROM returns a cartridge image of the given amount of 16KiB banks, each
+// starting with its bank number (low byte, high byte).
+func testROM(banks int, cartridgeType, ramSize byte) []byte {
+	rom := make([]byte, banks*ROM_BANK_SIZE)
+	for bank := 0; bank < banks; bank++ {
+		rom[bank*ROM_BANK_SIZE] = byte(bank)
+		rom[bank*ROM_BANK_SIZE+1] = byte(bank >> 8)
+	}
+	rom[CARTRIDGE_TYPE] = cartridgeType
+	rom[RAM_SIZE] = ramSize
+	return rom
+}
+
+// bankAt returns the number of the ROM bank mapped at address.
+func bankAt(m MemoryBankController, address uint16) int {
+	return int(m.RB(address)) | int(m.RB(address+1))<<8
+}
+
+type bankWrite struct {
+	address uint16
+	value   byte
+}
+
+func TestROMBanks(t *testing.T) {
+	tests := []struct {
+		name    string
+		rom     []byte
+		writes  []bankWrite
+		address uint16
+		want    int
+	}{
+		{"MBC1 bank 0 maps 1", testROM(8, 0x01, 0), []bankWrite{{0x2000, 0x00}}, 0x4000, 1},
+		{"MBC1 bank 0x20 maps 0x21", testROM(64, 0x01, 0), []bankWrite{{0x4000, 0x01}, {0x2000, 0x00}}, 0x4000, 0x21},
+		{"MBC1 upper bits", testROM(64, 0x01, 0), []bankWrite{{0x4000, 0x01}, {0x2000, 0x03}}, 0x4000, 0x23},
+		{"MBC1 mode 0 low area", testROM(64, 0x01, 0), []bankWrite{{0x4000, 0x01}}, 0x0000, 0},
+		{"MBC1 mode 1 low area", testROM(64, 0x01, 0), []bankWrite{{0x4000, 0x01}, {0x6000, 0x01}}, 0x0000, 0x20},
+		{"MBC1 wraps", testROM(4, 0x01, 0), []bankWrite{{0x2000, 0x05}}, 0x4000, 1},
+		{"MBC2 bank 0 maps 1", testROM(16, 0x05, 0), []bankWrite{{0x2100, 0x00}}, 0x4000, 1},
+		{"MBC2 A8 selects the bank", testROM(16, 0x05, 0), []bankWrite{{0x0100, 0x0A}}, 0x4000, 0x0A},
+		{"MBC2 A8 clear enables RAM", testROM(16, 0x05, 0), []bankWrite{{0x2000, 0x03}}, 0x4000, 1},
+		{"MBC3 bank 0 maps 1", testROM(8, 0x11, 0), []bankWrite{{0x2000, 0x00}}, 0x4000, 1},
+		{"MBC3 7-bit bank", testROM(128, 0x11, 0), []bankWrite{{0x2000, 0x7F}}, 0x4000, 0x7F},
+		{"MBC5 bank 0 maps 0", testROM(8, 0x19, 0), []bankWrite{{0x2000, 0x00}}, 0x4000, 0},
+		{"MBC5 9-bit bank", testROM(0x200, 0x19, 0), []bankWrite{{0x2000, 0x01}, {0x3000, 0x01}}, 0x4000, 0x101},
+		{"MBC5 bit 8 alone", testROM(0x200, 0x19, 0), []bankWrite{{0x3000, 0x01}, {0x2000, 0x00}}, 0x4000, 0x100},
+	}
+
+	for _, test := range tests {
+		m := New(test.rom)
+		for _, w := range test.writes {
+			m.WB(w.address, w.value)
+		}
+		if got := bankAt(m, test.address); got != test.want {
+			t.Errorf("%s: bank %X mapped at %04X, want %X", test.name, got, test.address, test.want)
+		}
+	}
+}
+
+func TestMBC1RAMBanks(t *testing.T) {
+	m := New(testROM(4, 0x03, 0x03))
+	m.WB(0x0000, 0x0A)
+
+	// mode 1 selects the RAM bank with the 2-bit register
+	m.WB(0x6000, 0x01)
+	m.WB(0x4000, 0x02)
+	m.WB(0xA000, 0x42)
+
+	m.WB(0x4000, 0x00)
+	if got := m.RB(0xA000); got != 0x00 {
+		t.Errorf("RAM bank 0 read %02X, want 00", got)
+	}
+
+	// mode 0 always maps bank 0
+	m.WB(0x4000, 0x02)
+	m.WB(0x6000, 0x00)
+	if got := m.RB(0xA000); got != 0x00 {
+		t.Errorf("mode 0 read %02X, want bank 0", got)
+	}
+
+	m.WB(0x6000, 0x01)
+	if got := m.RB(0xA000); got != 0x42 {
+		t.Errorf("RAM bank 2 read %02X, want 42", got)
+	}
+
+	m.WB(0x0000, 0x00)
+	if got := m.RB(0xA000); got != 0xFF {
+		t.Errorf("disabled RAM read %02X, want FF", got)
+	}
+}
+
+func TestMBC2RAM(t *testing.T) {
+	m := New(testROM(16, 0x06, 0))
+
+	// A8 set writes the ROM bank, the RAM stays disabled
+	m.WB(0x0100, 0x0A)
+	m.WB(0xA000, 0x05)
+	if got := m.RB(0xA000); got != 0xFF {
+		t.Errorf("disabled RAM read %02X, want FF", got)
+	}
+
+	m.WB(0x0000, 0x0A)
+	m.WB(0xA000, 0xAB)
+	if got := m.RB(0xA000); got != 0xFB {
+		t.Errorf("RAM read %02X, want the lower nibble B with the upper bits set", got)
+	}
+	// 512 half-bytes mirrored through 0xA000-0xBFFF
+	if got := m.RB(0xA200); got != 0xFB {
+		t.Errorf("RAM mirror read %02X, want FB", got)
+	}
+}
+
+func TestMBC3RTC(t *testing.T) {
+	m := New(testROM(4, 0x10, 0x03))
+	m.WB(0x0000, 0x0A)
+
+	m.WB(0x4000, 0x08) // seconds
+	m.WB(0xA000, 0x2A)
+	m.WB(0x4000, 0x0C) // day high
+	m.WB(0xA000, 0x01)
+
+	m.WB(0x4000, 0x00)
+	m.WB(0xA000, 0x99)
+
+	// values between the RAM banks and the clock registers are ignored
+	m.WB(0x4000, 0x08)
+	m.WB(0x4000, 0x05)
+	if got := m.RB(0xA000); got != 0x2A {
+		t.Errorf("seconds read %02X, want 2A", got)
+	}
+
+	m.WB(0x4000, 0x0C)
+	if got := m.RB(0xA000); got != 0x01 {
+		t.Errorf("day high read %02X, want 01", got)
+	}
+
+	m.WB(0x4000, 0x00)
+	if got := m.RB(0xA000); got != 0x99 {
+		t.Errorf("RAM bank 0 read %02X, want 99", got)
+	}
+}
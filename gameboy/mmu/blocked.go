@@ -0,0 +1,55 @@
+package mmu
+
+import "fmt"
+
+// BlockedAccess is a CPU access to VRAM or OAM ignored because the PPU was
+// using it, reads get 0xFF. PC is the address of the instruction doing it.
+type BlockedAccess struct {
+	PC      uint16
+	Address uint16
+	Write   bool
+	Value   byte
+	Mode    byte
+}
+
+func (e BlockedAccess) String() string {
+	area := "VRAM"
+	if e.Address >= OAM_START {
+		area = "OAM"
+	}
+
+	if e.Write {
+		return fmt.Sprintf("blocked %s write of 0x%02X to 0x%04X from PC 0x%04X in mode %d", area, e.Value, e.Address, e.PC, e.Mode)
+	}
+	return fmt.Sprintf("blocked %s read of 0x%04X from PC 0x%04X in mode %d", area, e.Address, e.PC, e.Mode)
+}
+
+// SetPCSource tells where to get the PC reported in the blocked accesses.
+func (m *MemoryManagementUnitImpl) SetPCSource(pc func() uint16) {
+	m.pc = pc
+}
+
+// OnBlockedAccess calls fn on every access the PPU mode blocks, nil removes
+// it.
+func (m *MemoryManagementUnitImpl) OnBlockedAccess(fn func(e BlockedAccess)) {
+	m.onBlocked = fn
+}
+
+func (m *MemoryManagementUnitImpl) blocked(address uint16, value byte, write bool) {
+	if m.onBlocked == nil {
+		return
+	}
+
+	var pc uint16
+	if m.pc != nil {
+		pc = m.pc()
+	}
+
+	m.onBlocked(BlockedAccess{
+		PC:      pc,
+		Address: address,
+		Write:   write,
+		Value:   value,
+		Mode:    m.ppu.mode,
+	})
+}
@@ -0,0 +1,47 @@
+package mmu
+
+const BOOT = 0xFF50 // Boot ROM disable
+
+const CGB_BOOTROM_SIZE = 0x900
+
+// bootROM is mapped over the cartridge from power on until something is
+// written to BOOT, the last instruction of the boot ROM does it. The CGB
+// image is also mapped at 0x0200-0x08FF, the cartridge header in between
+// stays visible.
+type bootROM struct {
+	data   []byte
+	mapped bool
+}
+
+func bootROMNew() *bootROM {
+	return &bootROM{}
+}
+
+func (m *bootROM) Init() {
+	m.mapped = len(m.data) > 0
+}
+
+func (m *bootROM) load(data []byte) {
+	m.data = data
+}
+
+func (m *bootROM) maps(address uint16) bool {
+	if !m.mapped {
+		return false
+	}
+
+	if address < BOOTROM_SIZE {
+		return true
+	}
+	return address >= 0x200 && int(address) < len(m.data)
+}
+
+func (m *bootROM) read(address uint16) byte {
+	return m.data[address]
+}
+
+func (m *bootROM) write(address uint16, v byte) {
+	if v != 0 {
+		m.mapped = false
+	}
+}
@@ -0,0 +1,72 @@
+package mmu
+
+import "github.com/brunocroh/gameboy/gameboy/scheduler"
+
+const DMA = 0xFF46 // OAM DMA source and start
+
+const (
+	dmaLength = 0xA0
+	// the first byte is copied after a setup machine cycle
+	dmaStartDelay = 8
+)
+
+// dma is the OAM DMA, writing DMA copies 160 bytes from value<<8 to OAM, one
+// byte per machine cycle. Sources from 0xE000 up read the echo of WRAM.
+type dma struct {
+	value  byte
+	source uint16
+	index  int
+	// the CPU reads OAM as 0xFF and can't write it until the copy ends
+	active bool
+
+	bus       func(address uint16) byte
+	oam       []byte
+	scheduler *scheduler.Scheduler
+}
+
+func dmaNew(s *scheduler.Scheduler, bus func(address uint16) byte, oam []byte) *dma {
+	m := &dma{
+		bus:       bus,
+		oam:       oam,
+		scheduler: s,
+	}
+
+	s.Handle(scheduler.OAMDMA, func(uint64) {
+		m.copy()
+	})
+
+	return m
+}
+
+func (m *dma) Init() {
+	m.value = 0xFF
+	m.active = false
+	m.scheduler.Cancel(scheduler.OAMDMA)
+}
+
+func (m *dma) read(address uint16) byte {
+	return m.value
+}
+
+func (m *dma) write(address uint16, value byte) {
+	m.value = value
+	m.source = uint16(value) << 8
+	if m.source >= ECHO_START {
+		m.source -= ECHO_START - WRAM_START
+	}
+
+	m.index = 0
+	m.active = true
+	m.scheduler.Schedule(scheduler.OAMDMA, dmaStartDelay)
+}
+
+func (m *dma) copy() {
+	m.oam[m.index] = m.bus(m.source + uint16(m.index))
+	m.index++
+
+	if m.index < dmaLength {
+		m.scheduler.Schedule(scheduler.OAMDMA, 4)
+		return
+	}
+	m.active = false
+}
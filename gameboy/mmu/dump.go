@@ -0,0 +1,130 @@
+package mmu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Region is a part of the memory map that can be dumped.
+type Region int
+
+const (
+	RegionROM Region = iota
+	RegionVRAM
+	RegionCartRAM
+	RegionWRAM
+	RegionOAM
+	RegionIO
+	RegionHRAM
+)
+
+var regionNames = map[Region]string{
+	RegionROM:     "rom",
+	RegionVRAM:    "vram",
+	RegionCartRAM: "sram",
+	RegionWRAM:    "wram",
+	RegionOAM:     "oam",
+	RegionIO:      "io",
+	RegionHRAM:    "hram",
+}
+
+func (r Region) String() string {
+	return regionNames[r]
+}
+
+// ParseRegion returns the region of a name: rom, vram, sram (cartridge RAM),
+// wram, oam, io or hram.
+func ParseRegion(name string) (Region, error) {
+	for region, regionName := range regionNames {
+		if strings.EqualFold(name, regionName) {
+			return region, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown region %q, use rom, vram, sram, wram, oam, io or hram", name)
+}
+
+// Base returns the address the region is mapped at, ROM banks other than 0
+// are mapped at 0x4000.
+func (r Region) Base(bank int) uint16 {
+	switch r {
+	case RegionROM:
+		if bank == 0 {
+			return 0x0000
+		}
+		return 0x4000
+	case RegionVRAM:
+		return VRAM_START
+	case RegionCartRAM:
+		return EXTERNAL_RAM_START
+	case RegionWRAM:
+		return WRAM_START
+	case RegionOAM:
+		return OAM_START
+	case RegionIO:
+		return IO_START
+	default:
+		return HRAM_START
+	}
+}
+
+// banked returns the bank of data, an error is returned for banks past the
+// end.
+func banked(data []byte, size int, region Region, bank int) ([]byte, error) {
+	start := bank * size
+	if bank < 0 || start >= len(data) {
+		return nil, fmt.Errorf("%s has no bank %d", region, bank)
+	}
+
+	end := start + size
+	if end > len(data) {
+		end = len(data)
+	}
+	return data[start:end], nil
+}
+
+// readRange reads a range through the bus, for the regions that are
+// registers rather than memory.
+func readRange(read func(uint16) byte, start uint16, length int) []byte {
+	data := make([]byte, length)
+	for i := range data {
+		data[i] = read(start + uint16(i))
+	}
+	return data
+}
+
+// HexDump formats data as lines of 16 bytes in hex and ASCII, prefixed by
+// their address.
+func HexDump(data []byte, base uint16) string {
+	var str strings.Builder
+
+	for line := 0; line < len(data); line += 16 {
+		end := line + 16
+		if end > len(data) {
+			end = len(data)
+		}
+
+		fmt.Fprintf(&str, "%04X ", int(base)+line)
+		for i := line; i < line+16; i++ {
+			if i == line+8 {
+				str.WriteString(" ")
+			}
+			if i < end {
+				fmt.Fprintf(&str, " %02X", data[i])
+			} else {
+				str.WriteString("   ")
+			}
+		}
+
+		str.WriteString("  |")
+		for _, b := range data[line:end] {
+			if b >= 0x20 && b < 0x7F {
+				str.WriteByte(b)
+			} else {
+				str.WriteByte('.')
+			}
+		}
+		str.WriteString("|\n")
+	}
+
+	return str.String()
+}
@@ -0,0 +1,72 @@
+package mmu
+
+// InterruptSource is the IE/IF bit of an interrupt, also its priority, the
+// lowest bit is served first.
+type InterruptSource byte
+
+const (
+	InterruptVBlank InterruptSource = 1 << iota
+	InterruptLCD
+	InterruptTimer
+	InterruptSerial
+	InterruptJoypad
+)
+
+// InterruptController owns IE and IF. Components request interrupts on it
+// and the CPU checks and acknowledges them directly, without going through
+// the bus.
+type InterruptController struct {
+	enabled   byte
+	requested byte
+}
+
+func InterruptControllerNew() *InterruptController {
+	return &InterruptController{}
+}
+
+func (m *InterruptController) Init() {
+	m.enabled = 0
+	m.requested = 0
+}
+
+// Request sets the IF bit of the source.
+func (m *InterruptController) Request(source InterruptSource) {
+	m.requested |= byte(source)
+}
+
+// Acknowledge clears the IF bit of the source, the CPU does it when the
+// interrupt is dispatched.
+func (m *InterruptController) Acknowledge(source InterruptSource) {
+	m.requested &^= byte(source)
+}
+
+// Pending returns the interrupts both requested and enabled.
+func (m *InterruptController) Pending() byte {
+	return m.enabled & m.requested & 0x1F
+}
+
+// Highest returns the pending interrupt with the highest priority, false
+// when there is none.
+func (m *InterruptController) Highest() (InterruptSource, bool) {
+	pending := m.Pending()
+	if pending == 0 {
+		return 0, false
+	}
+	return InterruptSource(pending & -pending), true
+}
+
+// the upper 3 bits of IF don't exist and read as 1, IE keeps all 8 bits
+func (m *InterruptController) read(address uint16) byte {
+	if address == IE {
+		return m.enabled
+	}
+	return 0xE0 | m.requested
+}
+
+func (m *InterruptController) write(address uint16, value byte) {
+	if address == IE {
+		m.enabled = value
+		return
+	}
+	m.requested = value & 0x1F
+}
@@ -0,0 +1,147 @@
+package mmu
+
+const (
+	IF = 0xFF0F // Interrupt flags
+	IE = 0xFFFF // Interrupt enable
+)
+
+// IOBus dispatches the I/O registers (0xFF00-0xFF7F) to the components that
+// mapped them. Each register has a read mask with the bits that always read
+// as 1, unused and write-only bits. Unmapped registers read 0xFF and ignore
+// writes.
+type IOBus struct {
+	registers [0x80]ioRegister
+}
+
+type ioRegister struct {
+	mapped bool
+	mask   byte
+	read   func(address uint16) byte
+	write  func(address uint16, value byte)
+}
+
+func IOBusNew() *IOBus {
+	return &IOBus{}
+}
+
+// Map hands the registers from start to end (inclusive) to a component. A nil
+// read makes them write-only, reading just the mask, and a nil write makes
+// them read-only.
+func (m *IOBus) Map(start, end uint16, mask byte, read func(address uint16) byte, write func(address uint16, value byte)) {
+	for address := start; address <= end; address++ {
+		m.registers[address-IO_START] = ioRegister{
+			mapped: true,
+			mask:   mask,
+			read:   read,
+			write:  write,
+		}
+	}
+}
+
+// Unmap removes the registers from start to end (inclusive).
+func (m *IOBus) Unmap(start, end uint16) {
+	for address := start; address <= end; address++ {
+		m.registers[address-IO_START] = ioRegister{}
+	}
+}
+
+func (m *IOBus) Mapped(address uint16) bool {
+	return m.registers[address-IO_START].mapped
+}
+
+func (m *IOBus) Read(address uint16) byte {
+	r := &m.registers[address-IO_START]
+	if !r.mapped {
+		return 0xFF
+	}
+	if r.read == nil {
+		return r.mask
+	}
+	return r.read(address) | r.mask
+}
+
+func (m *IOBus) Write(address uint16, value byte) {
+	r := &m.registers[address-IO_START]
+	if r.write != nil {
+		r.write(address, value)
+	}
+}
+
+// ioMemory backs the registers of the components that aren't emulated yet,
+// they read back what was written.
+type ioMemory struct {
+	values [0x80]byte
+}
+
+func ioMemoryNew() *ioMemory {
+	return &ioMemory{}
+}
+
+func (m *ioMemory) Init() {
+	m.values = [0x80]byte{}
+}
+
+func (m *ioMemory) read(address uint16) byte {
+	return m.values[address-IO_START]
+}
+
+func (m *ioMemory) write(address uint16, value byte) {
+	m.values[address-IO_START] = value
+}
+
+// ioMemoryMasks are the registers backed by ioMemory and their read masks.
+var ioMemoryMasks = []struct {
+	address uint16
+	mask    byte
+}{
+	{0xFF10, 0x80}, // NR10
+	{0xFF11, 0x3F}, // NR11
+	{0xFF12, 0x00}, // NR12
+	{0xFF13, 0xFF}, // NR13
+	{0xFF14, 0xBF}, // NR14
+	{0xFF16, 0x3F}, // NR21
+	{0xFF17, 0x00}, // NR22
+	{0xFF18, 0xFF}, // NR23
+	{0xFF19, 0xBF}, // NR24
+	{0xFF1A, 0x7F}, // NR30
+	{0xFF1B, 0xFF}, // NR31
+	{0xFF1C, 0x9F}, // NR32
+	{0xFF1D, 0xFF}, // NR33
+	{0xFF1E, 0xBF}, // NR34
+	{0xFF20, 0xFF}, // NR41
+	{0xFF21, 0x00}, // NR42
+	{0xFF22, 0x00}, // NR43
+	{0xFF23, 0xBF}, // NR44
+	{0xFF24, 0x00}, // NR50
+	{0xFF25, 0x00}, // NR51
+	{0xFF26, 0x70}, // NR52
+	{0xFF42, 0x00}, // SCY
+	{0xFF43, 0x00}, // SCX
+	{0xFF47, 0x00}, // BGP
+	{0xFF48, 0x00}, // OBP0
+	{0xFF49, 0x00}, // OBP1
+	{0xFF4A, 0x00}, // WY
+	{0xFF4B, 0x00}, // WX
+}
+
+// mapDevices maps the registers of the system components, shared by both
+// memory management units.
+func mapDevices(bus *IOBus, memory *ioMemory, interrupts *InterruptController, timer *Timer, serial *serial, joypad *joypad, speed *speed, boot *bootROM, dma *dma, ppu *ppu) {
+	bus.Map(P1, P1, 0xC0, joypad.read, joypad.write)
+	bus.Map(SB, SC, 0x00, serial.read, serial.write)
+	bus.Map(DIV, TAC, 0x00, timer.read, timer.write)
+	bus.Map(IF, IF, 0x00, interrupts.read, interrupts.write)
+	bus.Map(KEY1, KEY1, 0x00, speed.read, speed.write)
+	bus.Map(BOOT, BOOT, 0xFF, nil, boot.write)
+	bus.Map(DMA, DMA, 0x00, dma.read, dma.write)
+	bus.Map(LCDC, LCDC, 0x00, ppu.read, ppu.write)
+	bus.Map(STAT, STAT, 0x80, ppu.read, ppu.write)
+	bus.Map(LY, LY, 0x00, ppu.read, nil)
+	bus.Map(LYC, LYC, 0x00, ppu.read, ppu.write)
+
+	for _, r := range ioMemoryMasks {
+		bus.Map(r.address, r.address, r.mask, memory.read, memory.write)
+	}
+	// wave RAM
+	bus.Map(0xFF30, 0xFF3F, 0x00, memory.read, memory.write)
+}
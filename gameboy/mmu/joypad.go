@@ -0,0 +1,79 @@
+package mmu
+
+const P1 = 0xFF00 // Joypad
+
+// Button is a joypad key. The lower nibble are the d-pad lines and the upper
+// one the button lines, in the order P1 reads them.
+type Button byte
+
+const (
+	ButtonRight Button = 1 << iota
+	ButtonLeft
+	ButtonUp
+	ButtonDown
+	ButtonA
+	ButtonB
+	ButtonSelect
+	ButtonStart
+)
+
+// joypad holds the P1 register. Bits 4 and 5 select the button group and the
+// lower nibble reads its lines, where 0 means pressed. A line going low
+// requests the joypad interrupt.
+type joypad struct {
+	selected byte
+	pressed  Button
+
+	interrupts *InterruptController
+}
+
+func joypadNew(interrupts *InterruptController) *joypad {
+	return &joypad{
+		interrupts: interrupts,
+	}
+}
+
+func (m *joypad) Init() {
+	m.selected = 0x30
+	m.pressed = 0
+}
+
+// lines returns the lower nibble of P1, a line is low when one of the
+// selected groups has its key pressed.
+func (m *joypad) lines() byte {
+	var low byte
+	if m.selected&0x10 == 0 {
+		low |= byte(m.pressed) & 0x0F
+	}
+	if m.selected&0x20 == 0 {
+		low |= byte(m.pressed) >> 4
+	}
+	return 0x0F &^ low
+}
+
+// update requests the interrupt if a line went low since before.
+func (m *joypad) update(before byte) {
+	if before&^m.lines() != 0 {
+		m.interrupts.Request(InterruptJoypad)
+	}
+}
+
+func (m *joypad) read(address uint16) byte {
+	return 0xC0 | m.selected | m.lines()
+}
+
+func (m *joypad) write(address uint16, v byte) {
+	before := m.lines()
+	m.selected = v & 0x30
+	m.update(before)
+}
+
+func (m *joypad) setButton(button Button, pressed bool) {
+	before := m.lines()
+	if pressed {
+		m.pressed |= button
+	} else {
+		m.pressed &^= button
+	}
+	m.update(before)
+}
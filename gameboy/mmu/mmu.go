@@ -2,17 +2,26 @@ package mmu
 
 import (
 	"fmt"
-	"strings"
+	"io"
 
 	"github.com/brunocroh/gameboy/gameboy/mbc"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
 )
 
 const BOOTROM_SIZE = 256
 
 const ROM_START = 0x0100
+const VRAM_START = 0x8000
+const EXTERNAL_RAM_START = 0xA000
+const WRAM_START = 0xC000
+const ECHO_START = 0xE000
+const OAM_START = 0xFE00
+const UNUSABLE_START = 0xFEA0
+const IO_START = 0xFF00
 const HRAM_START = 0xFF80
 const HRAM_END = 0xFFFE
 
+// BOOTROM is the DMG boot ROM, run when no other image is loaded.
 var BOOTROM = [BOOTROM_SIZE]byte{
 	0x31, 0xfe, 0xff, 0xaf, 0x21, 0xff, 0x9f, 0x32, 0xcb, 0x7c, 0x20, 0xfb,
 	0x21, 0x26, 0xff, 0x0e, 0x11, 0x3e, 0x80, 0x32, 0xe2, 0x0c, 0x3e, 0xf3,
@@ -38,112 +47,345 @@ var BOOTROM = [BOOTROM_SIZE]byte{
 	0x3e, 0x01, 0xe0, 0x50,
 }
 
+// MemoryManagementUnit is the CPU view of the bus, a byte at a time. The CPU
+// composes words from two byte accesses.
 type MemoryManagementUnit interface {
-	Dump() string
+	// Dump copies a region, bank selects the ROM and cartridge RAM banks.
+	Dump(region Region, bank int) ([]byte, error)
 	Init(rom []byte)
 	RB(address uint16) byte
 	WB(address uint16, value byte)
-	RW(address uint16) uint16
-	DoCycle(ticks uint32)
+	// Peek returns what RB would without any side effect, for the debugging
+	// tools and everything else that isn't a CPU access.
+	Peek(address uint16) byte
+	// ROMBank returns the cartridge bank mapped at an address of 0x0000-0x7FFF.
+	ROMBank(address uint16) int
+	// IncDec tells the CPU increment/decrement unit put address on the bus
+	// this machine cycle, alongside a read of it when read is set. On DMG it
+	// corrupts OAM during the OAM scan.
+	IncDec(address uint16, read bool)
+	// SwitchSpeed performs the CGB speed switch if KEY1 has it prepared,
+	// reporting if it happened.
+	SwitchSpeed() bool
+	DoubleSpeed() bool
+	// SetButton presses or releases a joypad key.
+	SetButton(button Button, pressed bool)
+	// LoadBootROM maps the image over the cartridge on the next Init, nil
+	// starts with it already unmapped.
+	LoadBootROM(data []byte)
+	// SetSystemCounter sets the 16-bit counter DIV is the upper byte of.
+	SetSystemCounter(counter uint16)
+	// ResetDivider clears the system counter like a DIV write, without a bus
+	// access.
+	ResetDivider()
+	// Interrupts returns the controller owning IE and IF.
+	Interrupts() *InterruptController
+	// SetSerialOutput receives every byte sent through the link port, nil
+	// discards them.
+	SetSerialOutput(w io.Writer)
 }
 
+// MemoryManagementUnitImpl is the DMG memory map.
 type MemoryManagementUnitImpl struct {
-	hram [0x100]byte
-	wram [0x8000]byte
-	vram [0x4000]byte
-	mbc  *mbc.MemoryBankController
+	vram [0x2000]byte
+	wram [0x2000]byte
+	oam  [0xA0]byte
+	hram [0x7F]byte
+	mbc  mbc.MemoryBankController
 
-	timer *Timer
+	io         *IOBus
+	ioMemory   *ioMemory
+	interrupts *InterruptController
+	timer      *Timer
+	serial     *serial
+	joypad     *joypad
+	speed      *speed
+	boot       *bootROM
+	dma        *dma
+	ppu        *ppu
+
+	// last value on the external bus (cartridge and WRAM), reads nothing
+	// drives return it
+	bus byte
+	// OAM accesses during the OAM scan corrupt it, DMG only
+	oamBug bool
+
+	pc        func() uint16
+	onBlocked func(e BlockedAccess)
 }
 
-func NewMemoryManagementUnitImpl() *MemoryManagementUnitImpl {
-	timer := TimerNew()
-	mbc := mbc.New()
-	return &MemoryManagementUnitImpl{
-		timer: timer,
-		mbc:   mbc,
+func NewMemoryManagementUnitImpl(s *scheduler.Scheduler) *MemoryManagementUnitImpl {
+	m := &MemoryManagementUnitImpl{
+		mbc:        mbc.New(nil),
+		io:         IOBusNew(),
+		ioMemory:   ioMemoryNew(),
+		interrupts: InterruptControllerNew(),
+		speed:      speedNew(),
+		boot:       bootROMNew(),
+		oamBug:     true,
 	}
+	m.timer = TimerNew(s, m.interrupts)
+	m.serial = serialNew(s, m.interrupts)
+	m.joypad = joypadNew(m.interrupts)
+	m.dma = dmaNew(s, m.dmaRead, m.oam[:])
+	m.ppu = ppuNew(s, m.interrupts, m.speed)
+	mapDevices(m.io, m.ioMemory, m.interrupts, m.timer, m.serial, m.joypad, m.speed, m.boot, m.dma, m.ppu)
+	return m
 }
 
-func (m *MemoryManagementUnitImpl) Dump() string {
-	var str strings.Builder
-	str.WriteString("\n")
-	for i := 0; i < len(m.hram); i += 2 {
-		if i%16 == 0 && i != 0 {
-			str.WriteString("\n")
-		}
-		s := fmt.Sprintf("%02x%02x ", m.hram[i], m.hram[i+1])
-		str.WriteString(s)
-
+// Dump copies a region, bank selects the ROM and cartridge RAM banks.
+func (m *MemoryManagementUnitImpl) Dump(region Region, bank int) ([]byte, error) {
+	switch region {
+	case RegionROM:
+		return banked(m.mbc.ROM(), mbc.ROM_BANK_SIZE, region, bank)
+	case RegionCartRAM:
+		return banked(m.mbc.RAM(), mbc.RAM_BANK_SIZE, region, bank)
+	case RegionVRAM:
+		return append([]byte(nil), m.vram[:]...), nil
+	case RegionWRAM:
+		return append([]byte(nil), m.wram[:]...), nil
+	case RegionOAM:
+		return append([]byte(nil), m.oam[:]...), nil
+	case RegionIO:
+		return readRange(m.io.Read, IO_START, HRAM_START-IO_START), nil
+	case RegionHRAM:
+		return append([]byte(nil), m.hram[:]...), nil
 	}
-	str.WriteString("\n")
-	return strings.ToUpper(str.String())
+	return nil, fmt.Errorf("unknown region %d", region)
+}
+
+func (m *MemoryManagementUnitImpl) ROMBank(address uint16) int {
+	return m.mbc.ROMBank(address)
 }
 
 func (m *MemoryManagementUnitImpl) Init(rom []byte) {
-	m.hram = BOOTROM
-	m.timer.Init()
+	m.vram = [0x2000]byte{}
+	m.wram = [0x2000]byte{}
+	m.oam = [0xA0]byte{}
+	m.hram = [0x7F]byte{}
+	m.mbc = mbc.New(rom)
 
-	// for i, v := range rom {
-	// 	m.vram[ROM_START+i] = v
-	// }
+	m.ioMemory.Init()
+	m.interrupts.Init()
+	m.timer.Init()
+	m.serial.Init()
+	m.joypad.Init()
+	m.speed.Init(rom)
+	m.boot.Init()
+	m.dma.Init()
+	m.ppu.Init()
+	m.bus = 0xFF
 }
 
 func (m *MemoryManagementUnitImpl) RB(address uint16) byte {
-	switch address & 0xF000 {
-	case 0x1000:
-	case 0x2000:
-	case 0x3000:
-	case 0x4000:
-	case 0x5000:
-	case 0x6000:
-	case 0x7000:
-		return m.mbc.RB(address)
-	case 0x8000:
-	case 0x9000:
-		// GPU MEMORY
-		return 0xFF
-	case 0xC000:
-	case 0xD000:
-		return m.wram[address]
-	case 0xF000:
-		if m.timer.IsTimerAddress(address) {
-			return m.timer.read(address)
-		}
+	value := m.Peek(address)
 
-		if address < HRAM_END && address > HRAM_START {
-			return m.hram[address]
+	switch {
+	case m.boot.maps(address):
+	case address < VRAM_START:
+		m.bus = value
+	case address < EXTERNAL_RAM_START:
+		if m.ppu.vramBlocked() {
+			m.blocked(address, 0, false)
+		}
+	case address < WRAM_START:
+		if !m.mbc.OpenBus(address) {
+			m.bus = value
+		}
+	case address < OAM_START:
+		m.bus = value
+	case address < IO_START:
+		m.corruptOAM(oamRead)
+		if m.ppu.oamBlocked() {
+			m.blocked(address, 0, false)
 		}
 	}
 
-	return 0xFF
+	return value
 }
 
-func (m *MemoryManagementUnitImpl) WB(address uint16, value byte) {
-	if address == 0x4244 {
-		fmt.Print("trying write")
+func (m *MemoryManagementUnitImpl) Peek(address uint16) byte {
+	if m.boot.maps(address) {
+		return m.boot.read(address)
+	}
+
+	switch {
+	case address < VRAM_START:
+		return m.mbc.RB(address)
+	case address < EXTERNAL_RAM_START:
+		if m.ppu.vramBlocked() {
+			return 0xFF
+		}
+		return m.vram[address-VRAM_START]
+	case address < WRAM_START:
+		if m.mbc.OpenBus(address) {
+			return m.bus
+		}
+		return m.mbc.RB(address)
+	case address < ECHO_START:
+		return m.wram[address-WRAM_START]
+	case address < OAM_START:
+		// echo of 0xC000-0xDDFF
+		return m.wram[address-ECHO_START]
+	case address < IO_START:
+		if m.ppu.oamBlocked() || m.dma.active {
+			return 0xFF
+		}
+		if address >= UNUSABLE_START {
+			// unusable, reads 0 on DMG
+			return 0x00
+		}
+		return m.oam[address-OAM_START]
+	case address < HRAM_START:
+		return m.io.Read(address)
+	case address < IE:
+		return m.hram[address-HRAM_START]
+	default:
+		return m.interrupts.read(address)
 	}
-	if address < HRAM_END && address > HRAM_START {
-		m.hram[address] = value
+}
+
+func (m *MemoryManagementUnitImpl) WB(address uint16, value byte) {
+	switch {
+	case address < VRAM_START:
+		m.bus = value
+		m.mbc.WB(address, value)
+	case address < EXTERNAL_RAM_START:
+		if m.ppu.vramBlocked() {
+			m.blocked(address, value, true)
+			return
+		}
+		m.vram[address-VRAM_START] = value
+	case address < WRAM_START:
+		m.bus = value
+		m.mbc.WB(address, value)
+	case address < ECHO_START:
+		m.bus = value
+		m.wram[address-WRAM_START] = value
+	case address < OAM_START:
+		m.bus = value
+		m.wram[address-ECHO_START] = value
+	case address < IO_START:
+		m.corruptOAM(oamWrite)
+		if m.ppu.oamBlocked() {
+			m.blocked(address, value, true)
+			return
+		}
+		if m.dma.active {
+			return
+		}
+		// the unusable area ignores writes
+		if address < UNUSABLE_START {
+			m.oam[address-OAM_START] = value
+		}
+	case address < HRAM_START:
+		m.io.Write(address, value)
+	case address < IE:
+		m.hram[address-HRAM_START] = value
+	default:
+		m.interrupts.write(address, value)
 	}
+}
 
-	m.wram[address] = value
+// dmaRead is the OAM DMA access to its source, below 0xE000. The PPU doesn't
+// block it and it leaves the open bus latch alone.
+func (m *MemoryManagementUnitImpl) dmaRead(address uint16) byte {
+	switch {
+	case address < VRAM_START:
+		return m.mbc.RB(address)
+	case address < EXTERNAL_RAM_START:
+		return m.vram[address-VRAM_START]
+	case address < WRAM_START:
+		if m.mbc.OpenBus(address) {
+			return m.bus
+		}
+		return m.mbc.RB(address)
+	default:
+		return m.wram[address-WRAM_START]
+	}
 }
 
-func (m *MemoryManagementUnitImpl) RW(address uint16) uint16 {
-	var msb, lsb byte
+// IncDec corrupts OAM when address is in 0xFE00-0xFEFF during the OAM scan.
+func (m *MemoryManagementUnitImpl) IncDec(address uint16, read bool) {
+	if address < OAM_START || address >= IO_START {
+		return
+	}
 
-	if address < HRAM_END && address > HRAM_START {
-		lsb = m.hram[address]
-		msb = m.hram[address+1]
+	if read {
+		m.corruptOAM(oamReadIncDec)
 	} else {
-		lsb = m.wram[address]
-		msb = m.wram[address+1]
+		m.corruptOAM(oamWrite)
+	}
+}
+
+// corruptOAM glitches the row the OAM scan is reading, if it is running.
+func (m *MemoryManagementUnitImpl) corruptOAM(kind oamCorruption) {
+	if !m.oamBug {
+		return
+	}
+	if row, ok := m.ppu.oamRow(); ok {
+		corruptOAM(m.oam[:], row, kind)
 	}
+}
+
+// SetRegister sets an I/O register or IE straight on its component, to put
+// back the state the boot ROM leaves. Unlike WB, writing DMA doesn't start a
+// transfer.
+func (m *MemoryManagementUnitImpl) SetRegister(address uint16, value byte) {
+	switch address {
+	case DMA:
+		m.dma.value = value
+	case IE:
+		m.interrupts.write(address, value)
+	default:
+		m.io.Write(address, value)
+	}
+}
+
+// SetOAMBug chooses if OAM gets corrupted during the OAM scan, the CGB
+// doesn't have the bug. It is enabled by default.
+func (m *MemoryManagementUnitImpl) SetOAMBug(enabled bool) {
+	m.oamBug = enabled
+}
+
+// SetLYStub makes LY always read 0x90, the value gameboy-doctor logs expect.
+func (m *MemoryManagementUnitImpl) SetLYStub(enabled bool) {
+	m.ppu.lyStub = enabled
+}
+
+func (m *MemoryManagementUnitImpl) Interrupts() *InterruptController {
+	return m.interrupts
+}
+
+// IO returns the I/O register bus, components map their registers on it.
+func (m *MemoryManagementUnitImpl) IO() *IOBus {
+	return m.io
+}
+
+func (m *MemoryManagementUnitImpl) SwitchSpeed() bool {
+	return m.speed.switchSpeed()
+}
+
+func (m *MemoryManagementUnitImpl) DoubleSpeed() bool {
+	return m.speed.double
+}
+
+func (m *MemoryManagementUnitImpl) SetButton(button Button, pressed bool) {
+	m.joypad.setButton(button, pressed)
+}
+
+func (m *MemoryManagementUnitImpl) LoadBootROM(data []byte) {
+	m.boot.load(data)
+}
+
+func (m *MemoryManagementUnitImpl) SetSystemCounter(counter uint16) {
+	m.timer.setCounter(counter)
+}
 
-	return uint16(msb)<<8 | uint16(lsb)
+func (m *MemoryManagementUnitImpl) ResetDivider() {
+	m.timer.resetDivider()
 }
 
-func (m *MemoryManagementUnitImpl) DoCycle(ticks uint32) {
-	m.timer.DoCycle(ticks)
+func (m *MemoryManagementUnitImpl) SetSerialOutput(w io.Writer) {
+	m.serial.out = w
 }
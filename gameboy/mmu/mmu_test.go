@@ -0,0 +1,214 @@
+package mmu
+
+import (
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+func newTestMMU() (*MemoryManagementUnitImpl, *scheduler.Scheduler) {
+	s := scheduler.New()
+	m := NewMemoryManagementUnitImpl(s)
+	m.Init(make([]byte, 0x8000))
+	return m, s
+}
+
+func TestMemoryMap(t *testing.T) {
+	m, _ := newTestMMU()
+
+	m.WB(0xC123, 0x42)
+	if got := m.RB(0xE123); got != 0x42 {
+		t.Errorf("echo RAM read %02X, want 42", got)
+	}
+
+	m.WB(0xFD00, 0x24)
+	if got := m.RB(0xDD00); got != 0x24 {
+		t.Errorf("echo RAM write landed at %02X, want 24", got)
+	}
+
+	m.WB(0xFEA0, 0x11)
+	if got := m.RB(0xFEA0); got != 0x00 {
+		t.Errorf("unusable region read %02X, want 00", got)
+	}
+
+	m.WB(0x0000, 0x12)
+	if got := m.RB(0x0000); got != 0x00 {
+		t.Errorf("ROM was written, read %02X", got)
+	}
+
+	m.WB(0xFFFF, 0x1F)
+	m.WB(0xFF80, 0x33)
+	if m.RB(0xFFFF) != 0x1F || m.RB(0xFF80) != 0x33 {
+		t.Errorf("IE %02X HRAM %02X", m.RB(0xFFFF), m.RB(0xFF80))
+	}
+}
+
+func TestIOReadMasks(t *testing.T) {
+	m, _ := newTestMMU()
+
+	tests := []struct {
+		address uint16
+		write   byte
+		want    byte
+	}{
+		{IF, 0x01, 0xE1},
+		{0xFF03, 0x00, 0xFF}, // unmapped
+		{0xFF41, 0x00, 0x84}, // STAT, LY matches LYC
+		{0xFF26, 0x00, 0x70}, // NR52
+		{0xFF30, 0xA5, 0xA5}, // wave RAM
+		{0xFF47, 0xE4, 0xE4}, // BGP
+	}
+
+	for _, test := range tests {
+		m.WB(test.address, test.write)
+		if got := m.RB(test.address); got != test.want {
+			t.Errorf("%04X wrote %02X read %02X, want %02X", test.address, test.write, got, test.want)
+		}
+	}
+}
+
+func TestIOBusMap(t *testing.T) {
+	m, _ := newTestMMU()
+
+	var written byte
+	m.IO().Map(0xFF60, 0xFF61, 0xF0, func(uint16) byte {
+		return 0x05
+	}, func(address uint16, value byte) {
+		written = value
+	})
+
+	m.WB(0xFF61, 0x42)
+	if got := m.RB(0xFF60); got != 0xF5 || written != 0x42 {
+		t.Errorf("mapped register read %02X, wrote %02X", got, written)
+	}
+
+	m.IO().Map(0xFF62, 0xFF62, 0x0F, nil, nil)
+	if got := m.RB(0xFF62); got != 0x0F {
+		t.Errorf("write-only register read %02X, want its mask 0F", got)
+	}
+
+	m.IO().Unmap(0xFF60, 0xFF61)
+	if got := m.RB(0xFF60); got != 0xFF {
+		t.Errorf("unmapped register read %02X, want FF", got)
+	}
+}
+
+func TestOAMDMA(t *testing.T) {
+	m, s := newTestMMU()
+
+	for i := uint16(0); i < 0xA0; i++ {
+		m.WB(0xC000+i, byte(i))
+	}
+
+	m.WB(DMA, 0xC0)
+	m.WB(0xFE00, 0x42)
+	s.Advance(4 * 160)
+	if got := m.RB(0xFE00); got != 0xFF {
+		t.Errorf("OAM read %02X during the DMA, want FF", got)
+	}
+	if m.oam[0x9F] != 0x00 {
+		t.Errorf("last byte copied early, %02X", m.oam[0x9F])
+	}
+
+	s.Advance(4)
+	if got := m.RB(0xFE9F); got != 0x9F {
+		t.Errorf("last byte read %02X, want 9F", got)
+	}
+	if got := m.RB(0xFE00); got != 0x00 {
+		t.Errorf("first byte read %02X, want 00 as the write during the DMA is ignored", got)
+	}
+	if got := m.RB(DMA); got != 0xC0 {
+		t.Errorf("DMA read %02X, want C0", got)
+	}
+}
+
+func TestSetRegister(t *testing.T) {
+	m, s := newTestMMU()
+
+	m.WB(0xC000, 0x5A)
+	m.SetRegister(DMA, 0xC0)
+	m.SetRegister(IE, 0x1F)
+	m.SetRegister(TMA, 0x42)
+	s.Advance(dmaStartDelay + 4*dmaLength)
+
+	if got := m.RB(DMA); got != 0xC0 {
+		t.Errorf("DMA read %02X, want C0", got)
+	}
+	if m.oam[0] != 0x00 {
+		t.Error("setting DMA started a transfer")
+	}
+	if m.RB(IE) != 0x1F || m.RB(TMA) != 0x42 {
+		t.Errorf("IE %02X TMA %02X", m.RB(IE), m.RB(TMA))
+	}
+}
+
+func TestDump(t *testing.T) {
+	m, _ := newTestMMU()
+	m.WB(0xC010, 0x41)
+
+	wram, err := m.Dump(RegionWRAM, 0)
+	if err != nil || len(wram) != 0x2000 || wram[0x10] != 0x41 {
+		t.Fatalf("WRAM dump: %d bytes, %v", len(wram), err)
+	}
+
+	if _, err := m.Dump(RegionROM, 2); err == nil {
+		t.Error("dumped bank 2 of a 32KiB ROM")
+	}
+
+	want := "C010  41 00                                             |A.|\n"
+	if got := HexDump(wram[0x10:0x12], 0xC010); got != want {
+		t.Errorf("HexDump = %q, want %q", got, want)
+	}
+}
+
+func TestOpenBus(t *testing.T) {
+	m, _ := newTestMMU()
+
+	// the cartridge has no RAM
+	m.WB(0xC000, 0x5A)
+	if got := m.RB(0xA000); got != 0x5A {
+		t.Errorf("missing RAM read %02X, want the last WRAM write 5A", got)
+	}
+
+	m.RB(0x0000)
+	if got := m.RB(0xA000); got != 0x00 {
+		t.Errorf("missing RAM read %02X, want the last ROM read 00", got)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	m, s := newTestMMU()
+
+	var blocked []BlockedAccess
+	m.OnBlockedAccess(func(e BlockedAccess) {
+		blocked = append(blocked, e)
+	})
+
+	m.WB(0xC000, 0x5A)
+	if got := m.Peek(0x0000); got != 0x00 {
+		t.Errorf("ROM peek %02X, want 00", got)
+	}
+	if got := m.Peek(0xA000); got != 0x5A {
+		t.Errorf("missing RAM peek %02X, want the last WRAM write 5A", got)
+	}
+	if got := m.RB(0xA000); got != 0x5A {
+		t.Errorf("open bus moved by a peek, read %02X", got)
+	}
+
+	for i := range m.oam {
+		m.oam[i] = byte(i)
+	}
+	want := m.oam
+
+	m.WB(LCDC, 0x80)
+	s.Advance(8) // row 2 of the OAM scan
+	if got := m.Peek(0xFE10); got != 0xFF {
+		t.Errorf("OAM peek %02X in mode 2, want FF", got)
+	}
+	if m.oam != want {
+		t.Errorf("OAM corrupted by a peek, row 2 = % X", m.oam[0x10:0x18])
+	}
+	if len(blocked) != 0 {
+		t.Errorf("peek reported blocked accesses %+v", blocked)
+	}
+}
@@ -0,0 +1,60 @@
+package mmu
+
+// oamCorruption is the kind of access colliding with the OAM scan, see the
+// "OAM Corruption Bug" of Pan Docs for the patterns.
+type oamCorruption int
+
+const (
+	oamWrite oamCorruption = iota
+	oamRead
+	// a read in the same machine cycle as the increment/decrement unit, it
+	// is followed by a plain read corruption
+	oamReadIncDec
+)
+
+const (
+	oamRowSize = 8
+	oamRows    = 0xA0 / oamRowSize
+)
+
+// corruptOAM glitches the row the PPU is reading on DMG, OAM is seen as rows
+// of four 16-bit words. The first row is never corrupted.
+func corruptOAM(oam []byte, row int, kind oamCorruption) {
+	if row < 1 || row >= oamRows {
+		return
+	}
+
+	word := func(row, i int) uint16 {
+		offset := row*oamRowSize + i*2
+		return uint16(oam[offset]) | uint16(oam[offset+1])<<8
+	}
+	setWord := func(row, i int, v uint16) {
+		offset := row*oamRowSize + i*2
+		oam[offset] = byte(v)
+		oam[offset+1] = byte(v >> 8)
+	}
+	copyRow := func(to, from int) {
+		copy(oam[to*oamRowSize:(to+1)*oamRowSize], oam[from*oamRowSize:(from+1)*oamRowSize])
+	}
+
+	switch kind {
+	case oamWrite, oamRead:
+		a, b, c := word(row, 0), word(row-1, 0), word(row-1, 2)
+		if kind == oamWrite {
+			setWord(row, 0, ((a^c)&(b^c))^c)
+		} else {
+			setWord(row, 0, b|(a&c))
+		}
+		// the other three words come from the preceding row
+		copy(oam[row*oamRowSize+2:(row+1)*oamRowSize], oam[(row-1)*oamRowSize+2:row*oamRowSize])
+	case oamReadIncDec:
+		// neither the first four rows nor the last one
+		if row < 4 || row == oamRows-1 {
+			return
+		}
+		a, b, c, d := word(row-2, 0), word(row-1, 0), word(row, 0), word(row-1, 2)
+		setWord(row-1, 0, (b&(a|c|d))|(a&c&d))
+		copyRow(row-2, row-1)
+		copyRow(row, row-1)
+	}
+}
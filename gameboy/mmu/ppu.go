@@ -0,0 +1,217 @@
+package mmu
+
+import "github.com/brunocroh/gameboy/gameboy/scheduler"
+
+const (
+	LCDC = 0xFF40 // LCD control
+	STAT = 0xFF41 // LCD status
+	LY   = 0xFF44 // LCD Y coordinate
+	LYC  = 0xFF45 // LY compare
+)
+
+// PPU modes, the STAT bits 0-1
+const (
+	ModeHBlank   = 0
+	ModeVBlank   = 1
+	ModeOAMScan  = 2
+	ModeTransfer = 3
+)
+
+// dots of each part of a line, the transfer length doesn't vary with the
+// objects and scrolling yet
+const (
+	oamScanDots  = 80
+	transferDots = 172
+	lineDots     = 456
+
+	visibleLines = 144
+	lines        = 154
+)
+
+// ly read by gameboy-doctor logs, the boot ROM waits for it too
+const lyStubValue = 0x90
+
+// ppu is the LCD timing, it doesn't draw anything. It walks through the
+// modes of every line with PPUMode events, updating LY and STAT and
+// requesting the VBlank and STAT interrupts.
+type ppu struct {
+	lcdc byte
+	stat byte // interrupt enable bits 3-6
+	ly   byte
+	lyc  byte
+	mode byte
+
+	// timestamp where the current line started
+	lineStart uint64
+	// level of the STAT interrupt line, the interrupt fires on rising edges
+	statLine bool
+	// LY always reads lyStubValue
+	lyStub bool
+
+	scheduler  *scheduler.Scheduler
+	interrupts *InterruptController
+	speed      *speed
+}
+
+func ppuNew(s *scheduler.Scheduler, interrupts *InterruptController, speed *speed) *ppu {
+	m := &ppu{
+		scheduler:  s,
+		interrupts: interrupts,
+		speed:      speed,
+	}
+
+	s.Handle(scheduler.PPUMode, func(uint64) {
+		m.nextMode()
+	})
+
+	return m
+}
+
+func (m *ppu) Init() {
+	m.lcdc = 0
+	m.stat = 0
+	m.ly = 0
+	m.lyc = 0
+	m.mode = ModeHBlank
+	m.statLine = false
+	m.scheduler.Cancel(scheduler.PPUMode)
+}
+
+func (m *ppu) enabled() bool {
+	return m.lcdc&0x80 != 0
+}
+
+// dotCycles returns the T-cycles a dot takes, the PPU doesn't speed up in
+// CGB double speed mode.
+func (m *ppu) dotCycles() uint64 {
+	if m.speed.double {
+		return 2
+	}
+	return 1
+}
+
+// dot returns the position in the current line.
+func (m *ppu) dot() int {
+	return int((m.scheduler.Now() - m.lineStart) / m.dotCycles())
+}
+
+// oamRow returns the row of OAM (8 bytes, two objects) the OAM scan is
+// reading, ok is false outside of it.
+func (m *ppu) oamRow() (row int, ok bool) {
+	if !m.enabled() || m.mode != ModeOAMScan {
+		return 0, false
+	}
+	return m.dot() / 4, true
+}
+
+// vramBlocked reports if the CPU can't access VRAM, the PPU is reading it
+// during the transfer.
+func (m *ppu) vramBlocked() bool {
+	return m.enabled() && m.mode == ModeTransfer
+}
+
+// oamBlocked reports if the CPU can't access OAM, the PPU is reading it
+// during the OAM scan and the transfer.
+func (m *ppu) oamBlocked() bool {
+	return m.enabled() && (m.mode == ModeOAMScan || m.mode == ModeTransfer)
+}
+
+func (m *ppu) setMode(mode byte, dots int) {
+	m.mode = mode
+	m.scheduler.ScheduleAt(scheduler.PPUMode, m.lineStart+uint64(dots)*m.dotCycles())
+	m.updateStatLine()
+}
+
+// nextMode moves to the next mode, scheduling the one after.
+func (m *ppu) nextMode() {
+	switch m.mode {
+	case ModeOAMScan:
+		m.setMode(ModeTransfer, oamScanDots+transferDots)
+	case ModeTransfer:
+		m.setMode(ModeHBlank, lineDots)
+	case ModeHBlank, ModeVBlank:
+		m.lineStart += lineDots * m.dotCycles()
+		m.ly = (m.ly + 1) % lines
+		m.startLine()
+	}
+}
+
+func (m *ppu) startLine() {
+	switch {
+	case m.ly < visibleLines:
+		m.setMode(ModeOAMScan, oamScanDots)
+	case m.ly == visibleLines:
+		m.interrupts.Request(InterruptVBlank)
+		m.setMode(ModeVBlank, lineDots)
+	default:
+		m.setMode(ModeVBlank, lineDots)
+	}
+}
+
+// updateStatLine requests the STAT interrupt when one of the enabled
+// conditions starts being true while none was.
+func (m *ppu) updateStatLine() {
+	line := false
+	if m.enabled() {
+		line = m.stat&0x40 != 0 && m.ly == m.lyc ||
+			m.stat&0x20 != 0 && m.mode == ModeOAMScan ||
+			m.stat&0x10 != 0 && m.mode == ModeVBlank ||
+			m.stat&0x08 != 0 && m.mode == ModeHBlank
+	}
+
+	if line && !m.statLine {
+		m.interrupts.Request(InterruptLCD)
+	}
+	m.statLine = line
+}
+
+func (m *ppu) read(address uint16) byte {
+	switch address {
+	case LCDC:
+		return m.lcdc
+	case STAT:
+		v := m.stat | m.mode
+		if m.ly == m.lyc {
+			v |= 0x04
+		}
+		return v
+	case LY:
+		if m.lyStub {
+			return lyStubValue
+		}
+		return m.ly
+	default:
+		return m.lyc
+	}
+}
+
+func (m *ppu) write(address uint16, value byte) {
+	switch address {
+	case LCDC:
+		m.writeLCDC(value)
+	case STAT:
+		m.stat = value & 0x78
+	case LYC:
+		m.lyc = value
+	}
+	m.updateStatLine()
+}
+
+// writeLCDC starts the LCD from the first line when bit 7 is set, clearing
+// it stops it on line 0 in mode 0.
+func (m *ppu) writeLCDC(value byte) {
+	on := value&0x80 != 0
+	wasOn := m.enabled()
+	m.lcdc = value
+
+	switch {
+	case on && !wasOn:
+		m.lineStart = m.scheduler.Now()
+		m.ly = 0
+		m.startLine()
+	case !on && wasOn:
+		m.ly = 0
+		m.mode = ModeHBlank
+		m.scheduler.Cancel(scheduler.PPUMode)
+	}
+}
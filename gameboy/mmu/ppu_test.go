@@ -0,0 +1,176 @@
+package mmu
+
+import "testing"
+
+func TestPPUModes(t *testing.T) {
+	m, s := newTestMMU()
+
+	m.WB(LYC, 2)
+	m.WB(STAT, 0x40)
+	m.WB(LCDC, 0x80)
+
+	steps := []struct {
+		cycles uint64
+		ly     byte
+		mode   byte
+	}{
+		{0, 0, ModeOAMScan},
+		{oamScanDots, 0, ModeTransfer},
+		{transferDots, 0, ModeHBlank},
+		{lineDots - oamScanDots - transferDots, 1, ModeOAMScan},
+	}
+	for _, step := range steps {
+		s.Advance(step.cycles)
+		if ly, mode := m.RB(LY), m.RB(STAT)&0x03; ly != step.ly || mode != step.mode {
+			t.Fatalf("at %d LY = %d, mode = %d, want %d, %d", s.Now(), ly, mode, step.ly, step.mode)
+		}
+	}
+
+	if m.interrupts.requested != 0 {
+		t.Fatalf("IF = %02X before LY matched LYC", m.interrupts.requested)
+	}
+	s.Advance(lineDots)
+	if m.interrupts.requested != byte(InterruptLCD) || m.RB(STAT)&0x04 == 0 {
+		t.Errorf("IF = %02X, STAT = %02X on the LYC line", m.interrupts.requested, m.RB(STAT))
+	}
+
+	s.Advance(lineDots * (visibleLines - 2))
+	if m.RB(LY) != visibleLines || m.interrupts.requested&byte(InterruptVBlank) == 0 {
+		t.Errorf("LY = %d, IF = %02X, want VBlank", m.RB(LY), m.interrupts.requested)
+	}
+
+	m.SetLYStub(true)
+	if got := m.RB(LY); got != 0x90 {
+		t.Errorf("stubbed LY read %02X", got)
+	}
+
+	m.WB(LCDC, 0x00)
+	if ly, mode := m.RB(LY), m.RB(STAT)&0x03; ly != 0x90 || mode != ModeHBlank {
+		t.Errorf("LCD off: LY = %d, mode = %d", ly, mode)
+	}
+}
+
+func TestOAMCorruption(t *testing.T) {
+	m, s := newTestMMU()
+
+	for i := range m.oam {
+		m.oam[i] = byte(i)
+	}
+	want := m.oam
+
+	m.WB(LCDC, 0x80)
+	s.Advance(8) // row 2 of the OAM scan
+
+	m.IncDec(0xFE10, false)
+
+	// ((a ^ c) & (b ^ c)) ^ c with the words 0x1110, 0x0908 and 0x0D0C
+	// gives 0x0908, the rest of the row is copied from row 1
+	copy(want[0x10:0x18], want[0x08:0x10])
+	if m.oam != want {
+		t.Errorf("write corruption: row 2 = % X", m.oam[0x10:0x18])
+	}
+
+	m.IncDec(0xC000, false)
+	m.IncDec(0xFE10, true) // row 2 is out of reach of a read during increase
+	if m.oam != want {
+		t.Errorf("OAM corrupted from outside FE00-FEFF, row 2 = % X", m.oam[0x10:0x18])
+	}
+
+	s.Advance(oamScanDots)
+	m.IncDec(0xFE10, false)
+	if m.oam != want {
+		t.Errorf("OAM corrupted outside the OAM scan, row 2 = % X", m.oam[0x10:0x18])
+	}
+
+	// the CGB doesn't have the bug
+	m, s = newTestMMU()
+	m.SetOAMBug(false)
+	for i := range m.oam {
+		m.oam[i] = byte(i)
+	}
+	want = m.oam
+
+	m.WB(LCDC, 0x80)
+	s.Advance(8)
+	m.IncDec(0xFE10, false)
+	m.RB(0xFE10)
+	if m.oam != want {
+		t.Errorf("OAM corrupted with the bug disabled, row 2 = % X", m.oam[0x10:0x18])
+	}
+}
+
+func TestAccessBlocking(t *testing.T) {
+	m, s := newTestMMU()
+	m.SetPCSource(func() uint16 { return 0x0150 })
+
+	var blocked []BlockedAccess
+	m.OnBlockedAccess(func(e BlockedAccess) {
+		blocked = append(blocked, e)
+	})
+
+	m.WB(0x8000, 0x11)
+	m.WB(0xFE00, 0x22)
+	m.WB(LCDC, 0x80)
+
+	// OAM scan
+	m.WB(0xFE01, 0x33)
+	m.WB(0x8001, 0x44)
+	if got := m.RB(0xFE00); got != 0xFF {
+		t.Errorf("OAM read %02X in mode 2", got)
+	}
+
+	s.Advance(oamScanDots)
+	m.WB(0x8000, 0x55)
+	if got := m.RB(0x8000); got != 0xFF {
+		t.Errorf("VRAM read %02X in mode 3", got)
+	}
+
+	s.Advance(transferDots)
+	if vram, oam := m.RB(0x8000), m.RB(0xFE00); vram != 0x11 || oam != 0x22 {
+		t.Errorf("in mode 0 VRAM read %02X, OAM read %02X", vram, oam)
+	}
+	if m.vram[1] != 0x44 || m.oam[1] != 0x00 {
+		t.Errorf("VRAM write in mode 2 %02X, OAM write %02X", m.vram[1], m.oam[1])
+	}
+
+	want := []BlockedAccess{
+		{PC: 0x0150, Address: 0xFE01, Write: true, Value: 0x33, Mode: ModeOAMScan},
+		{PC: 0x0150, Address: 0xFE00, Mode: ModeOAMScan},
+		{PC: 0x0150, Address: 0x8000, Write: true, Value: 0x55, Mode: ModeTransfer},
+		{PC: 0x0150, Address: 0x8000, Mode: ModeTransfer},
+	}
+	if len(blocked) != len(want) {
+		t.Fatalf("blocked = %+v", blocked)
+	}
+	for i := range want {
+		if blocked[i] != want[i] {
+			t.Errorf("blocked access %d = %+v, want %+v", i, blocked[i], want[i])
+		}
+	}
+}
+
+func TestDMADuringTransfer(t *testing.T) {
+	m, s := newTestMMU()
+
+	var blocked []BlockedAccess
+	m.OnBlockedAccess(func(e BlockedAccess) {
+		blocked = append(blocked, e)
+	})
+
+	for i := range m.vram[:0xA0] {
+		m.vram[i] = byte(i) + 1
+	}
+
+	m.WB(LCDC, 0x80)
+	s.Advance(oamScanDots)
+	m.WB(DMA, 0x80)
+	s.Advance(dmaStartDelay)
+
+	s.Advance(4)
+	if m.oam[0] != 0x01 || m.oam[1] != 0x02 {
+		t.Errorf("DMA copied % X from VRAM in mode 3, want 01 02", m.oam[:2])
+	}
+	if len(blocked) != 0 {
+		t.Errorf("DMA reported blocked accesses %+v", blocked)
+	}
+}
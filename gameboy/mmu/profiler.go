@@ -0,0 +1,292 @@
+package mmu
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/brunocroh/gameboy/gameboy/mbc"
+)
+
+// accesses further apart than this are reported as different ranges
+const profileRangeGap = 16
+
+// the longest instruction, opcode and a 16-bit operand
+const maxInstructionLength = 3
+
+// romCounts are the accesses to the 16KiB of a ROM bank, wherever it was
+// mapped.
+type romCounts struct {
+	fetches [mbc.ROM_BANK_SIZE]uint64
+	reads   [mbc.ROM_BANK_SIZE]uint64
+}
+
+// ProfileRange is a run of accessed addresses, Bank is -1 outside of the ROM.
+type ProfileRange struct {
+	Bank  int
+	Start uint16
+	End   uint16
+	Count uint64
+}
+
+func (r ProfileRange) String() string {
+	if r.Bank < 0 {
+		return fmt.Sprintf("%04X-%04X", r.Start, r.End)
+	}
+	return fmt.Sprintf("%02X:%04X-%04X", r.Bank, r.Start, r.End)
+}
+
+// Profiler decorates a MemoryManagementUnit counting the reads, writes and
+// instruction fetches of every address, and of every ROM bank.
+//
+// The CPU tells where each opcode is fetched from, the operand bytes are the
+// reads following it at the next addresses.
+type Profiler struct {
+	MemoryManagementUnit
+
+	fetches [0x10000]uint64
+	reads   [0x10000]uint64
+	writes  [0x10000]uint64
+	rom     map[int]*romCounts
+
+	// next address of the instruction being fetched and bytes left of it
+	fetchAddress uint16
+	fetchLeft    int
+}
+
+func NewProfiler(inner MemoryManagementUnit) *Profiler {
+	return &Profiler{
+		MemoryManagementUnit: inner,
+		rom:                  map[int]*romCounts{},
+	}
+}
+
+// Reset clears the counters.
+func (m *Profiler) Reset() {
+	m.fetches = [0x10000]uint64{}
+	m.reads = [0x10000]uint64{}
+	m.writes = [0x10000]uint64{}
+	m.rom = map[int]*romCounts{}
+	m.fetchLeft = 0
+}
+
+// Fetch marks the next read of address as an opcode fetch.
+func (m *Profiler) Fetch(address uint16) {
+	m.fetchAddress = address
+	m.fetchLeft = maxInstructionLength
+}
+
+func (m *Profiler) bank(address uint16) *romCounts {
+	bank := m.MemoryManagementUnit.ROMBank(address)
+	counts, ok := m.rom[bank]
+	if !ok {
+		counts = &romCounts{}
+		m.rom[bank] = counts
+	}
+	return counts
+}
+
+func (m *Profiler) RB(address uint16) byte {
+	fetch := m.fetchLeft > 0 && address == m.fetchAddress
+	if fetch {
+		m.fetchAddress++
+		m.fetchLeft--
+		m.fetches[address]++
+	} else {
+		m.fetchLeft = 0
+		m.reads[address]++
+	}
+
+	if address < VRAM_START {
+		counts := m.bank(address)
+		if fetch {
+			counts.fetches[address%mbc.ROM_BANK_SIZE]++
+		} else {
+			counts.reads[address%mbc.ROM_BANK_SIZE]++
+		}
+	}
+
+	return m.MemoryManagementUnit.RB(address)
+}
+
+func (m *Profiler) WB(address uint16, value byte) {
+	m.fetchLeft = 0
+	m.writes[address]++
+	m.MemoryManagementUnit.WB(address, value)
+}
+
+// CodeRanges returns the n ranges with the most instruction fetches, the ROM
+// ones by bank.
+func (m *Profiler) CodeRanges(n int) []ProfileRange {
+	count := func(address int) uint64 {
+		return m.fetches[address]
+	}
+	romCount := func(counts *romCounts, offset int) uint64 {
+		return counts.fetches[offset]
+	}
+
+	return m.hottest(count, romCount, n)
+}
+
+// DataRanges returns the n ranges with the most reads and writes, instruction
+// fetches aside.
+func (m *Profiler) DataRanges(n int) []ProfileRange {
+	count := func(address int) uint64 {
+		return m.reads[address] + m.writes[address]
+	}
+	romCount := func(counts *romCounts, offset int) uint64 {
+		return counts.reads[offset]
+	}
+
+	return m.hottest(count, romCount, n)
+}
+
+func (m *Profiler) hottest(count func(address int) uint64, romCount func(counts *romCounts, offset int) uint64, n int) []ProfileRange {
+	var ranges []ProfileRange
+
+	banks := make([]int, 0, len(m.rom))
+	for bank := range m.rom {
+		banks = append(banks, bank)
+	}
+	sort.Ints(banks)
+
+	// a bank is shown at the address it is usually mapped at
+	for _, bank := range banks {
+		counts := m.rom[bank]
+		base := 0
+		if bank != 0 {
+			base = mbc.ROM_BANK_SIZE
+		}
+		found := profileRanges(mbc.ROM_BANK_SIZE, func(offset int) uint64 {
+			return romCount(counts, offset)
+		})
+		for _, r := range found {
+			r.Bank = bank
+			r.Start += uint16(base)
+			r.End += uint16(base)
+			ranges = append(ranges, r)
+		}
+	}
+
+	for _, r := range profileRanges(0x10000-VRAM_START, func(offset int) uint64 {
+		return count(VRAM_START + offset)
+	}) {
+		r.Bank = -1
+		r.Start += VRAM_START
+		r.End += VRAM_START
+		ranges = append(ranges, r)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Count > ranges[j].Count
+	})
+	if len(ranges) > n {
+		ranges = ranges[:n]
+	}
+	return ranges
+}
+
+// profileRanges groups the accessed offsets of 0 to size-1, joining the ones
+// less than profileRangeGap apart.
+func profileRanges(size int, count func(offset int) uint64) []ProfileRange {
+	var ranges []ProfileRange
+	var current *ProfileRange
+
+	for offset := 0; offset < size; offset++ {
+		c := count(offset)
+		if c == 0 {
+			continue
+		}
+
+		if current != nil && offset-int(current.End) <= profileRangeGap {
+			current.End = uint16(offset)
+			current.Count += c
+			continue
+		}
+
+		ranges = append(ranges, ProfileRange{Start: uint16(offset), End: uint16(offset), Count: c})
+		current = &ranges[len(ranges)-1]
+	}
+
+	return ranges
+}
+
+// WriteReport writes the n hottest code and data ranges and the accesses to
+// every ROM bank.
+func (m *Profiler) WriteReport(w io.Writer, n int) error {
+	var err error
+	printf := func(format string, a ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, a...)
+		}
+	}
+
+	printf("hottest code ranges\n")
+	for _, r := range m.CodeRanges(n) {
+		printf("  %-15s %12d fetches\n", r, r.Count)
+	}
+
+	printf("hottest data ranges\n")
+	for _, r := range m.DataRanges(n) {
+		printf("  %-15s %12d accesses\n", r, r.Count)
+	}
+
+	banks := make([]int, 0, len(m.rom))
+	for bank := range m.rom {
+		banks = append(banks, bank)
+	}
+	sort.Ints(banks)
+
+	printf("rom banks\n")
+	for _, bank := range banks {
+		var fetches, reads uint64
+		counts := m.rom[bank]
+		for i := range counts.fetches {
+			fetches += counts.fetches[i]
+			reads += counts.reads[i]
+		}
+		printf("  %02X %12d fetches %12d reads\n", bank, fetches, reads)
+	}
+
+	return err
+}
+
+// WriteHeatmap writes a 256x256 PNG of the address space, a row per 256
+// bytes. Writes are red, reads green and fetches blue, each on a log scale.
+func (m *Profiler) WriteHeatmap(w io.Writer) error {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 256))
+
+	scale := func(counts *[0x10000]uint64) func(c uint64) uint8 {
+		var max uint64
+		for _, c := range counts {
+			if c > max {
+				max = c
+			}
+		}
+		top := math.Log1p(float64(max))
+		return func(c uint64) uint8 {
+			if c == 0 {
+				return 0
+			}
+			return uint8(255 * math.Log1p(float64(c)) / top)
+		}
+	}
+	red := scale(&m.writes)
+	green := scale(&m.reads)
+	blue := scale(&m.fetches)
+
+	for address := 0; address < 0x10000; address++ {
+		img.SetRGBA(address%256, address/256, color.RGBA{
+			R: red(m.writes[address]),
+			G: green(m.reads[address]),
+			B: blue(m.fetches[address]),
+			A: 0xFF,
+		})
+	}
+
+	return png.Encode(w, img)
+}
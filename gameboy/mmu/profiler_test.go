@@ -0,0 +1,72 @@
+package mmu
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestProfiler(t *testing.T) {
+	inner, _ := newTestMMU()
+	rom := make([]byte, 0x10000)
+	rom[0x147] = 0x01 // MBC1
+	inner.Init(rom)
+
+	p := NewProfiler(inner)
+
+	// an opcode with a 16-bit operand, then a data read
+	p.Fetch(0x0150)
+	p.RB(0x0150)
+	p.RB(0x0151)
+	p.RB(0x0152)
+	p.RB(0xC000)
+
+	// a write breaks the instruction bytes
+	p.Fetch(0x0153)
+	p.RB(0x0153)
+	p.WB(0xC001, 0x42)
+	p.RB(0x0154)
+
+	p.WB(0x2000, 0x02)
+	p.Fetch(0x4000)
+	p.RB(0x4000)
+
+	code := p.CodeRanges(10)
+	want := []ProfileRange{
+		{Bank: 0, Start: 0x0150, End: 0x0153, Count: 4},
+		{Bank: 2, Start: 0x4000, End: 0x4000, Count: 1},
+	}
+	if len(code) != len(want) {
+		t.Fatalf("code ranges = %v", code)
+	}
+	for i := range want {
+		if code[i] != want[i] {
+			t.Errorf("code range %d = %+v, want %+v", i, code[i], want[i])
+		}
+	}
+
+	data := p.DataRanges(1)
+	if len(data) != 1 || data[0] != (ProfileRange{Bank: -1, Start: 0xC000, End: 0xC001, Count: 2}) {
+		t.Errorf("data ranges = %+v", data)
+	}
+
+	var report bytes.Buffer
+	if err := p.WriteReport(&report, 10); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(report.Bytes(), []byte("02:4000-4000")) {
+		t.Errorf("report doesn't show bank 2:\n%s", report.String())
+	}
+
+	var heatmap bytes.Buffer
+	if err := p.WriteHeatmap(&heatmap); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&heatmap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 256 || b.Dy() != 256 {
+		t.Errorf("heatmap is %dx%d", b.Dx(), b.Dy())
+	}
+}
@@ -0,0 +1,92 @@
+package mmu
+
+import (
+	"io"
+
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+const (
+	SB = 0xFF01 // Serial transfer data
+	SC = 0xFF02 // Serial transfer control
+)
+
+// with the internal 8192Hz clock a bit is shifted every 512 cycles
+const serialBitCycles = 512
+
+// serial is the link port. There is never a cable connected, so every
+// received bit is 1 and only transfers driven by the internal clock complete.
+// The sent bytes are written to out, test ROMs print their results this way.
+type serial struct {
+	sb byte
+	sc byte
+
+	// bits left in the current transfer and the byte being sent
+	bits    int
+	sending byte
+	out     io.Writer
+
+	scheduler  *scheduler.Scheduler
+	interrupts *InterruptController
+}
+
+func serialNew(s *scheduler.Scheduler, interrupts *InterruptController) *serial {
+	m := &serial{
+		scheduler:  s,
+		interrupts: interrupts,
+	}
+
+	s.Handle(scheduler.SerialBit, func(uint64) {
+		m.shift()
+	})
+
+	return m
+}
+
+func (m *serial) Init() {
+	m.sb = 0
+	m.sc = 0
+	m.bits = 0
+	m.scheduler.Cancel(scheduler.SerialBit)
+}
+
+func (m *serial) read(address uint16) byte {
+	if address == SB {
+		return m.sb
+	}
+	return 0x7E | m.sc
+}
+
+func (m *serial) write(address uint16, v byte) {
+	if address == SB {
+		m.sb = v
+		return
+	}
+
+	m.sc = v & 0x81
+	if m.sc == 0x81 {
+		m.bits = 8
+		m.sending = m.sb
+		m.scheduler.Schedule(scheduler.SerialBit, serialBitCycles)
+	} else {
+		m.bits = 0
+		m.scheduler.Cancel(scheduler.SerialBit)
+	}
+}
+
+func (m *serial) shift() {
+	m.sb = m.sb<<1 | 1
+	m.bits--
+
+	if m.bits > 0 {
+		m.scheduler.Schedule(scheduler.SerialBit, serialBitCycles)
+		return
+	}
+
+	m.sc &^= 0x80
+	m.interrupts.Request(InterruptSerial)
+
+	if m.out != nil {
+		m.out.Write([]byte{m.sending})
+	}
+}
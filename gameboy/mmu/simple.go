@@ -2,79 +2,152 @@ package mmu
 
 import (
 	"fmt"
-	"strings"
+	"io"
+
+	"github.com/brunocroh/gameboy/gameboy/mbc"
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
 )
 
 type MemoryManagementUnitSimple struct {
 	memory_arr [0xFFFFF]byte
+	io         *IOBus
+	ioMemory   *ioMemory
+	interrupts *InterruptController
 	timer      *Timer
+	serial     *serial
+	joypad     *joypad
+	boot       *bootROM
+	speed      *speed
+	dma        *dma
+	ppu        *ppu
 }
 
-func NewMemoryManagementUnitSimple() *MemoryManagementUnitSimple {
-	timer := TimerNew()
-	return &MemoryManagementUnitSimple{
-		timer: timer,
+func NewMemoryManagementUnitSimple(s *scheduler.Scheduler) *MemoryManagementUnitSimple {
+	m := &MemoryManagementUnitSimple{
+		io:         IOBusNew(),
+		ioMemory:   ioMemoryNew(),
+		interrupts: InterruptControllerNew(),
+		speed:      speedNew(),
+		boot:       bootROMNew(),
 	}
+	m.timer = TimerNew(s, m.interrupts)
+	m.serial = serialNew(s, m.interrupts)
+	m.joypad = joypadNew(m.interrupts)
+	m.dma = dmaNew(s, m.Peek, m.memory_arr[OAM_START:UNUSABLE_START])
+	m.ppu = ppuNew(s, m.interrupts, m.speed)
+	mapDevices(m.io, m.ioMemory, m.interrupts, m.timer, m.serial, m.joypad, m.speed, m.boot, m.dma, m.ppu)
+	return m
 }
 
-func (m *MemoryManagementUnitSimple) Dump() string {
-	var str strings.Builder
-	str.WriteString("\n")
-	region := ROM_START - 16
-	for i := 0; i < 32; i += 2 {
-		if i%16 == 0 && i != 0 {
-			str.WriteString("\n")
-		}
-		s := fmt.Sprintf("%02x%02x ", m.memory_arr[region+i], m.memory_arr[region+i+1])
-		str.WriteString(s)
-
+// Dump copies a region, there is no bank switching so the ROM only has the
+// two banks mapped at 0x0000 and 0x4000 and the cartridge RAM one.
+func (m *MemoryManagementUnitSimple) Dump(region Region, bank int) ([]byte, error) {
+	switch region {
+	case RegionROM:
+		return banked(m.memory_arr[:VRAM_START], mbc.ROM_BANK_SIZE, region, bank)
+	case RegionCartRAM:
+		return banked(m.memory_arr[EXTERNAL_RAM_START:WRAM_START], mbc.RAM_BANK_SIZE, region, bank)
+	case RegionVRAM:
+		return append([]byte(nil), m.memory_arr[VRAM_START:EXTERNAL_RAM_START]...), nil
+	case RegionWRAM:
+		return append([]byte(nil), m.memory_arr[WRAM_START:ECHO_START]...), nil
+	case RegionOAM:
+		return append([]byte(nil), m.memory_arr[OAM_START:UNUSABLE_START]...), nil
+	case RegionIO:
+		return readRange(m.io.Read, IO_START, HRAM_START-IO_START), nil
+	case RegionHRAM:
+		return append([]byte(nil), m.memory_arr[HRAM_START:IE]...), nil
 	}
-	str.WriteString("\n")
-	return strings.ToUpper(str.String())
+	return nil, fmt.Errorf("unknown region %d", region)
+}
+
+// ROMBank returns 0 or 1, the ROM is mapped as it is.
+func (m *MemoryManagementUnitSimple) ROMBank(address uint16) int {
+	return int(address / mbc.ROM_BANK_SIZE)
 }
 
+// IncDec does nothing, the OAM corruption bug isn't emulated.
+func (m *MemoryManagementUnitSimple) IncDec(address uint16, read bool) {}
+
 func (m *MemoryManagementUnitSimple) Init(rom []byte) {
+	m.ioMemory.Init()
+	m.interrupts.Init()
 	m.timer.Init()
-
-	for i, v := range BOOTROM {
-		m.memory_arr[HRAM_START+i] = v
-	}
+	m.serial.Init()
+	m.joypad.Init()
+	m.speed.Init(rom)
+	m.boot.Init()
+	m.dma.Init()
+	m.ppu.Init()
 
 	copy(m.memory_arr[:], rom)
 }
 
 func (m *MemoryManagementUnitSimple) RB(address uint16) byte {
-	switch address {
-	// LCD is not implemented so return hardcoded value for it works
-	case 0xFF44:
-		return 0x90
-	case 0xFF04, 0xFF05, 0xFF06, 0xFF07:
-		return m.timer.read(address)
-	default:
-		return m.memory_arr[address]
+	if m.boot.maps(address) {
+		return m.boot.read(address)
 	}
+
+	if address >= IO_START && address < HRAM_START {
+		return m.io.Read(address)
+	}
+	if address == IE {
+		return m.interrupts.read(address)
+	}
+
+	return m.memory_arr[address]
+}
+
+func (m *MemoryManagementUnitSimple) Peek(address uint16) byte {
+	return m.RB(address)
 }
 
 func (m *MemoryManagementUnitSimple) WB(address uint16, value byte) {
-	switch address {
-	case 0xFF04, 0xFF05, 0xFF06, 0xFF07:
-		m.timer.write(address, value)
-	default:
-		m.memory_arr[address] = value
+	if address >= IO_START && address < HRAM_START {
+		m.io.Write(address, value)
+		return
+	}
+	if address == IE {
+		m.interrupts.write(address, value)
+		return
 	}
+
+	m.memory_arr[address] = value
 }
 
-func (m *MemoryManagementUnitSimple) RW(address uint16) uint16 {
-	var lsb = m.memory_arr[address]
-	var msb = m.memory_arr[address+1]
+func (m *MemoryManagementUnitSimple) Interrupts() *InterruptController {
+	return m.interrupts
+}
 
-	return uint16(msb)<<8 | uint16(lsb)
+// IO returns the I/O register bus, components map their registers on it.
+func (m *MemoryManagementUnitSimple) IO() *IOBus {
+	return m.io
 }
 
-func (m *MemoryManagementUnitSimple) DoCycle(ticks uint32) {
-	m.timer.DoCycle(ticks)
-	if m.timer.Interrupt != 0 {
-		m.memory_arr[0xFF0F] |= m.timer.Interrupt
-		m.timer.Interrupt = 0
-	}
+func (m *MemoryManagementUnitSimple) SwitchSpeed() bool {
+	return m.speed.switchSpeed()
+}
+
+func (m *MemoryManagementUnitSimple) DoubleSpeed() bool {
+	return m.speed.double
+}
+
+func (m *MemoryManagementUnitSimple) SetButton(button Button, pressed bool) {
+	m.joypad.setButton(button, pressed)
+}
+
+func (m *MemoryManagementUnitSimple) LoadBootROM(data []byte) {
+	m.boot.load(data)
+}
+
+func (m *MemoryManagementUnitSimple) SetSystemCounter(counter uint16) {
+	m.timer.setCounter(counter)
+}
+
+func (m *MemoryManagementUnitSimple) ResetDivider() {
+	m.timer.resetDivider()
+}
+
+func (m *MemoryManagementUnitSimple) SetSerialOutput(w io.Writer) {
+	m.serial.out = w
 }
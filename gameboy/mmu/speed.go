@@ -0,0 +1,53 @@
+package mmu
+
+const KEY1 = 0xFF4D // CGB speed switch
+
+// speed holds the CGB KEY1 register, the switch itself is performed by the
+// STOP instruction.
+type speed struct {
+	cgb     bool
+	double  bool
+	prepare bool
+}
+
+func speedNew() *speed {
+	return &speed{}
+}
+
+func (m *speed) Init(rom []byte) {
+	// CGB flag in the cartridge header
+	m.cgb = len(rom) > 0x143 && rom[0x143]&0x80 != 0
+	m.double = false
+	m.prepare = false
+}
+
+func (m *speed) read(address uint16) byte {
+	if !m.cgb {
+		return 0xFF
+	}
+
+	v := byte(0x7E)
+	if m.double {
+		v |= 0x80
+	}
+	if m.prepare {
+		v |= 0x01
+	}
+	return v
+}
+
+func (m *speed) write(address uint16, v byte) {
+	if m.cgb {
+		m.prepare = v&0x01 != 0
+	}
+}
+
+func (m *speed) switchSpeed() bool {
+	if !m.cgb || !m.prepare {
+		return false
+	}
+
+	m.double = !m.double
+	m.prepare = false
+	return true
+}
@@ -1,5 +1,7 @@
 package mmu
 
+import "github.com/brunocroh/gameboy/gameboy/scheduler"
+
 const (
 	DIV  = 0xFF04 // Divider
 	TIMA = 0xFF05 // Counter
@@ -7,30 +9,84 @@ const (
 	TAC  = 0xFF07 // Control
 )
 
+// TIMA is reloaded one machine cycle after it overflows
+const timerReloadDelay = 4
+
+// Timer is driven by the 16-bit system counter, DIV being its upper byte.
+// TIMA increments on the falling edge of the counter bit selected by TAC
+// ANDed with the enable bit, so resetting DIV or changing TAC can tick it too.
+//
+// It doesn't tick every cycle, it catches up from the scheduler clock when one
+// of its registers is accessed and keeps a TimerOverflow event scheduled for
+// the next overflow or reload so the interrupt is requested at the right moment.
 type Timer struct {
-	divider         byte
-	internalDivider uint32
-	counter         byte
-	internalCounter uint32
-	modulo          byte
-	step            uint32
-	enabled         bool
-	Interrupt       byte
+	// system counter, unwrapped so falling edges can be counted by division
+	counter uint64
+	tima    byte
+	tma     byte
+	tac     byte
+
+	// TIMA overflowed and reads 0 until it is reloaded with TMA at reloadAt
+	reloading bool
+	reloadAt  uint64
+	// timestamp of the last reload, writes in that cycle see special behavior
+	reloadedAt uint64
+
+	scheduler  *scheduler.Scheduler
+	lastSync   uint64
+	interrupts *InterruptController
 }
 
-func TimerNew() *Timer {
-	return &Timer{}
+func TimerNew(s *scheduler.Scheduler, interrupts *InterruptController) *Timer {
+	t := &Timer{
+		scheduler:  s,
+		interrupts: interrupts,
+	}
+
+	s.Handle(scheduler.TimerOverflow, func(uint64) {
+		t.catchUp()
+		t.schedule()
+	})
+
+	return t
 }
 
 func (m *Timer) Init() {
-	m.enabled = true
-	m.divider = 0
-	m.internalDivider = 0
 	m.counter = 0
-	m.internalCounter = 0
-	m.modulo = 0
-	m.step = 256
-	m.Interrupt = 0
+	m.tima = 0
+	m.tma = 0
+	m.tac = 0
+	m.reloading = false
+	m.reloadAt = 0
+	m.reloadedAt = ^uint64(0)
+	m.lastSync = m.scheduler.Now()
+	m.schedule()
+}
+
+// setCounter moves the system counter without the side effects of a DIV
+// write, it restores the value left by the boot ROM.
+func (m *Timer) setCounter(v uint16) {
+	m.catchUp()
+	m.counter = uint64(v)
+	m.schedule()
+}
+
+// resetDivider clears the system counter the way a DIV write does, outside
+// of a bus access. STOP uses it.
+func (m *Timer) resetDivider() {
+	m.catchUp()
+	m.resetCounter(m.scheduler.Now())
+	m.schedule()
+}
+
+// resetCounter clears the system counter, the falling edge of the selected
+// bit increments TIMA.
+func (m *Timer) resetCounter(now uint64) {
+	wasHigh := m.signal()
+	m.counter = 0
+	if wasHigh {
+		m.increment(now)
+	}
 }
 
 func (m *Timer) IsTimerAddress(address uint16) bool {
@@ -41,76 +97,132 @@ func (m *Timer) IsTimerAddress(address uint16) bool {
 }
 
 func (m *Timer) read(address uint16) byte {
+	m.catchUp()
+
 	switch address {
 	case DIV:
-		return m.divider
+		return byte(m.counter >> 8)
 	case TIMA:
-		return m.counter
+		return m.tima
 	case TMA:
-		return m.modulo
+		return m.tma
 	default:
-		var stepCount = byte(0)
-
-		switch m.step {
-		case 16:
-			stepCount = 1
-		case 64:
-			stepCount = 2
-		case 256:
-			stepCount = 3
-		default:
-			stepCount = byte(0)
-		}
-
-		if m.enabled {
-			return 0xF8 | 0x4 | stepCount
-		} else {
-			return 0xF8 | stepCount
-		}
+		return 0xF8 | m.tac
 	}
 }
 
 func (m *Timer) write(address uint16, v byte) {
+	m.catchUp()
+	now := m.scheduler.Now()
+
 	switch address {
 	case DIV:
-		m.divider = 0
+		m.resetCounter(now)
 	case TIMA:
-		m.counter = v
+		switch {
+		case m.reloading:
+			// writing in the cycle after the overflow aborts the reload
+			m.reloading = false
+			m.tima = v
+		case m.reloadedAt == now:
+			// TMA is being loaded in this cycle, the write is lost
+		default:
+			m.tima = v
+		}
 	case TMA:
-		m.modulo = v
+		m.tma = v
+		if m.reloadedAt == now {
+			m.tima = v
+		}
 	case TAC:
-		m.enabled = (v & 0x4) != 0
-		switch v & 0x3 {
-		case 1:
-			m.step = 16
-		case 2:
-			m.step = 64
-		case 3:
-			m.step = 256
-		default:
-			m.step = 1024
+		wasHigh := m.signal()
+		m.tac = v & 0x07
+		if wasHigh && !m.signal() {
+			m.increment(now)
 		}
 	}
+
+	m.schedule()
+}
+
+// period returns how many cycles the selected counter bit takes to go through
+// a whole low/high cycle.
+func (m *Timer) period() uint64 {
+	switch m.tac & 0x03 {
+	case 1:
+		return 16 // bit 3
+	case 2:
+		return 64 // bit 5
+	case 3:
+		return 256 // bit 7
+	default:
+		return 1024 // bit 9
+	}
 }
 
-func (m *Timer) DoCycle(ticks uint32) {
-	m.internalDivider += ticks
+func (m *Timer) enabled() bool {
+	return m.tac&0x04 != 0
+}
+
+// signal is the input of the falling edge detector.
+func (m *Timer) signal() bool {
+	return m.enabled() && m.counter&(m.period()>>1) != 0
+}
 
-	for m.internalDivider >= 256 {
-		m.divider = m.divider + 1
-		m.internalDivider -= 256
+func (m *Timer) increment(at uint64) {
+	m.tima++
+	if m.tima == 0 {
+		m.reloading = true
+		m.reloadAt = at + timerReloadDelay
 	}
+}
+
+// catchUp applies every cycle elapsed since the last access.
+func (m *Timer) catchUp() {
+	now := m.scheduler.Now()
 
-	if m.enabled {
-		m.internalCounter += ticks
+	if m.enabled() {
+		period := m.period()
+		// counter value at the first falling edge after the last sync
+		edge := (m.counter/period + 1) * period
 
-		for m.internalCounter >= m.step {
-			m.counter = m.counter + 1
-			if m.counter == 0 {
-				m.counter = m.modulo
-				m.Interrupt |= 0x04
-			}
-			m.internalCounter -= m.step
+		for m.lastSync+edge-m.counter <= now {
+			m.applyReload(m.lastSync + edge - m.counter)
+			m.increment(m.lastSync + edge - m.counter)
+			edge += period
 		}
 	}
+
+	m.applyReload(now)
+
+	m.counter += now - m.lastSync
+	m.lastSync = now
+}
+
+func (m *Timer) applyReload(now uint64) {
+	if m.reloading && m.reloadAt <= now {
+		m.reloading = false
+		m.reloadedAt = m.reloadAt
+		m.tima = m.tma
+		m.interrupts.Request(InterruptTimer)
+	}
+}
+
+// schedule sets the TimerOverflow event to the next reload or overflow.
+func (m *Timer) schedule() {
+	if m.reloading {
+		m.scheduler.ScheduleAt(scheduler.TimerOverflow, m.reloadAt)
+		return
+	}
+
+	if !m.enabled() {
+		m.scheduler.Cancel(scheduler.TimerOverflow)
+		return
+	}
+
+	period := m.period()
+	edge := (m.counter/period + 1) * period
+	increments := uint64(0x100 - uint16(m.tima))
+
+	m.scheduler.Schedule(scheduler.TimerOverflow, edge-m.counter+(increments-1)*period)
 }
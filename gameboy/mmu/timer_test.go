@@ -0,0 +1,103 @@
+package mmu
+
+import (
+	"testing"
+
+	"github.com/brunocroh/gameboy/gameboy/scheduler"
+)
+
+// newTestTimer starts TIMA at tima, counting every 16 cycles, with TMA 0xAB.
+// The requested interrupts are collected in the returned controller.
+func newTestTimer(tima byte) (*Timer, *scheduler.Scheduler, *InterruptController) {
+	s := scheduler.New()
+	interrupts := InterruptControllerNew()
+	m := TimerNew(s, interrupts)
+	m.Init()
+
+	m.write(TMA, 0xAB)
+	m.write(TIMA, tima)
+	m.write(TAC, 0x05)
+	return m, s, interrupts
+}
+
+func TestTimerOverflow(t *testing.T) {
+	m, s, interrupts := newTestTimer(0xFF)
+
+	s.Advance(16)
+	if got := m.read(TIMA); got != 0x00 {
+		t.Errorf("TIMA read %02X right after the overflow, want 00", got)
+	}
+	if interrupts.requested&byte(InterruptTimer) != 0 {
+		t.Error("timer interrupt requested before the reload")
+	}
+
+	s.Advance(timerReloadDelay)
+	if got := m.read(TIMA); got != 0xAB {
+		t.Errorf("TIMA read %02X after the reload, want TMA AB", got)
+	}
+	if interrupts.requested&byte(InterruptTimer) == 0 {
+		t.Error("timer interrupt not requested by the reload")
+	}
+}
+
+func TestTimerReloadWrites(t *testing.T) {
+	// writing TIMA in the cycle after the overflow aborts the reload
+	m, s, interrupts := newTestTimer(0xFF)
+	s.Advance(16)
+	m.write(TIMA, 0x10)
+	s.Advance(timerReloadDelay)
+	if got := m.read(TIMA); got != 0x10 {
+		t.Errorf("TIMA read %02X after an aborted reload, want 10", got)
+	}
+	if interrupts.requested&byte(InterruptTimer) != 0 {
+		t.Error("timer interrupt requested by an aborted reload")
+	}
+
+	// writing TIMA in the reload cycle is lost
+	m, s, _ = newTestTimer(0xFF)
+	s.Advance(16 + timerReloadDelay)
+	m.write(TIMA, 0x10)
+	if got := m.read(TIMA); got != 0xAB {
+		t.Errorf("TIMA read %02X after a write in the reload cycle, want AB", got)
+	}
+
+	// writing TMA in the reload cycle goes to TIMA too
+	m, s, _ = newTestTimer(0xFF)
+	s.Advance(16 + timerReloadDelay)
+	m.write(TMA, 0x33)
+	if got := m.read(TIMA); got != 0x33 {
+		t.Errorf("TIMA read %02X after a TMA write in the reload cycle, want 33", got)
+	}
+
+	// afterwards TMA is only used by the next reload
+	s.Advance(4)
+	m.write(TMA, 0x44)
+	if got := m.read(TIMA); got != 0x33 {
+		t.Errorf("TIMA read %02X after a later TMA write, want 33", got)
+	}
+}
+
+func TestTimerExtraIncrements(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed uint64
+		address uint16
+		value   byte
+		want    byte
+	}{
+		{"DIV reset with bit 3 high", 8, DIV, 0x00, 0x01},
+		{"DIV reset with bit 3 low", 4, DIV, 0x00, 0x00},
+		{"TAC selecting a low bit", 8, TAC, 0x04, 0x01},
+		{"TAC disabling", 8, TAC, 0x01, 0x01},
+		{"TAC unchanged", 8, TAC, 0x05, 0x00},
+	}
+
+	for _, test := range tests {
+		m, s, _ := newTestTimer(0x00)
+		s.Advance(test.elapsed)
+		m.write(test.address, test.value)
+		if got := m.read(TIMA); got != test.want {
+			t.Errorf("%s: TIMA read %02X, want %02X", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package mmu
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WatchKind selects the accesses a watchpoint triggers on, kinds can be
+// combined.
+type WatchKind int
+
+const (
+	WatchRead WatchKind = 1 << iota
+	WatchWrite
+	// WatchChange triggers on writes changing the value read back, the
+	// unused bits of the registers and ignored writes aren't changes
+	WatchChange
+)
+
+func (k WatchKind) String() string {
+	var kinds []string
+	if k&WatchRead != 0 {
+		kinds = append(kinds, "read")
+	}
+	if k&WatchWrite != 0 {
+		kinds = append(kinds, "write")
+	}
+	if k&WatchChange != 0 {
+		kinds = append(kinds, "change")
+	}
+	return strings.Join(kinds, "|")
+}
+
+// WatchEvent is an access that triggered a watchpoint. PC is the address of
+// the instruction doing it, Old and New are the same for reads.
+type WatchEvent struct {
+	ID      int
+	Kind    WatchKind
+	PC      uint16
+	Address uint16
+	Old     byte
+	New     byte
+}
+
+func (e *WatchEvent) Error() string {
+	return fmt.Sprintf("watchpoint %d: %s at 0x%04X from PC 0x%04X, 0x%02X -> 0x%02X",
+		e.ID, e.Kind, e.Address, e.PC, e.Old, e.New)
+}
+
+type watchpoint struct {
+	id       int
+	start    uint16
+	end      uint16
+	kind     WatchKind
+	pause    bool
+	callback func(e WatchEvent)
+}
+
+// Watcher decorates a MemoryManagementUnit with watchpoints on address
+// ranges. Accesses outside of them go straight to the wrapped unit.
+type Watcher struct {
+	MemoryManagementUnit
+
+	watchpoints []watchpoint
+	nextID      int
+	pc          func() uint16
+
+	// set by a pausing watchpoint until the emulation loop takes it
+	paused *WatchEvent
+}
+
+func NewWatcher(inner MemoryManagementUnit) *Watcher {
+	return &Watcher{
+		MemoryManagementUnit: inner,
+		nextID:               1,
+		pc: func() uint16 {
+			return 0
+		},
+	}
+}
+
+// SetPCSource tells where to get the PC reported in the events.
+func (m *Watcher) SetPCSource(pc func() uint16) {
+	m.pc = pc
+}
+
+// Watch adds a watchpoint from start to end (inclusive) and returns its id.
+// The callback can be nil, a pausing watchpoint stops the emulation loop
+// after the current instruction.
+func (m *Watcher) Watch(start, end uint16, kind WatchKind, pause bool, callback func(e WatchEvent)) int {
+	id := m.nextID
+	m.nextID++
+
+	m.watchpoints = append(m.watchpoints, watchpoint{
+		id:       id,
+		start:    start,
+		end:      end,
+		kind:     kind,
+		pause:    pause,
+		callback: callback,
+	})
+
+	return id
+}
+
+// Unwatch removes a watchpoint, it is a no-op if the id doesn't exist.
+func (m *Watcher) Unwatch(id int) {
+	for i, w := range m.watchpoints {
+		if w.id == id {
+			m.watchpoints = append(m.watchpoints[:i], m.watchpoints[i+1:]...)
+			return
+		}
+	}
+}
+
+// TakePause returns the event that paused the emulation, if any, and clears
+// it.
+func (m *Watcher) TakePause() (*WatchEvent, bool) {
+	e := m.paused
+	m.paused = nil
+	return e, e != nil
+}
+
+func (m *Watcher) watched(address uint16, kind WatchKind) bool {
+	for _, w := range m.watchpoints {
+		if w.kind&kind != 0 && address >= w.start && address <= w.end {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Watcher) trigger(address uint16, old, value byte, write, changed bool) {
+	for _, w := range m.watchpoints {
+		if address < w.start || address > w.end {
+			continue
+		}
+
+		var kind WatchKind
+		switch {
+		case !write:
+			kind = w.kind & WatchRead
+		case changed:
+			kind = w.kind & (WatchWrite | WatchChange)
+		default:
+			kind = w.kind & WatchWrite
+		}
+		if kind == 0 {
+			continue
+		}
+
+		e := WatchEvent{
+			ID:      w.id,
+			Kind:    kind,
+			PC:      m.pc(),
+			Address: address,
+			Old:     old,
+			New:     value,
+		}
+
+		if w.callback != nil {
+			w.callback(e)
+		}
+		if w.pause && m.paused == nil {
+			m.paused = &e
+		}
+	}
+}
+
+func (m *Watcher) RB(address uint16) byte {
+	value := m.MemoryManagementUnit.RB(address)
+
+	if len(m.watchpoints) > 0 && m.watched(address, WatchRead) {
+		m.trigger(address, value, value, false, false)
+	}
+
+	return value
+}
+
+func (m *Watcher) WB(address uint16, value byte) {
+	if len(m.watchpoints) == 0 || !m.watched(address, WatchWrite|WatchChange) {
+		m.MemoryManagementUnit.WB(address, value)
+		return
+	}
+
+	old := m.MemoryManagementUnit.Peek(address)
+	m.MemoryManagementUnit.WB(address, value)
+	changed := m.MemoryManagementUnit.Peek(address) != old
+	m.trigger(address, old, value, true, changed)
+}
@@ -0,0 +1,101 @@
+package mmu
+
+import "testing"
+
+func TestWatcher(t *testing.T) {
+	inner, _ := newTestMMU()
+	w := NewWatcher(inner)
+	w.SetPCSource(func() uint16 { return 0x0150 })
+
+	var events []WatchEvent
+	record := func(e WatchEvent) {
+		events = append(events, e)
+	}
+
+	w.Watch(0xC000, 0xC00F, WatchChange, false, record)
+	read := w.Watch(0xC100, 0xC100, WatchRead, true, record)
+
+	w.WB(0xC000, 0x00) // same value, not a change
+	w.WB(0xC001, 0x42)
+	w.WB(0xC010, 0x42) // outside the range
+	w.RB(0xC100)
+
+	want := []WatchEvent{
+		{ID: 1, Kind: WatchChange, PC: 0x0150, Address: 0xC001, Old: 0x00, New: 0x42},
+		{ID: 2, Kind: WatchRead, PC: 0x0150, Address: 0xC100},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v", events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], want[i])
+		}
+	}
+
+	if e, ok := w.TakePause(); !ok || e.ID != read {
+		t.Errorf("pause = %+v, %t", e, ok)
+	}
+	if _, ok := w.TakePause(); ok {
+		t.Error("pause wasn't cleared")
+	}
+
+	w.Unwatch(read)
+	w.RB(0xC100)
+	if len(events) != 2 {
+		t.Errorf("removed watchpoint triggered")
+	}
+}
+
+func TestWatcherMaskedAndBlocked(t *testing.T) {
+	inner, s := newTestMMU()
+	w := NewWatcher(inner)
+
+	var blocked []BlockedAccess
+	inner.OnBlockedAccess(func(e BlockedAccess) {
+		blocked = append(blocked, e)
+	})
+
+	var events []WatchEvent
+	w.Watch(IF, IF, WatchChange, false, func(e WatchEvent) {
+		events = append(events, e)
+	})
+	w.Watch(0xFE00, 0xFE9F, WatchWrite|WatchChange, false, func(e WatchEvent) {
+		events = append(events, e)
+	})
+
+	// IF reads its upper bits as 1
+	w.WB(IF, 0x01)
+	w.WB(IF, 0x01)
+
+	for i := range inner.oam {
+		inner.oam[i] = byte(i)
+	}
+	want := inner.oam
+
+	w.WB(LCDC, 0x80)
+	s.Advance(8) // row 2 of the OAM scan
+	w.WB(0xFE00, 0x42)
+
+	wantEvents := []WatchEvent{
+		{ID: 1, Kind: WatchChange, Address: IF, Old: 0xE0, New: 0x01},
+		{ID: 2, Kind: WatchWrite, Address: 0xFE00, Old: 0xFF, New: 0x42},
+	}
+	if len(events) != len(wantEvents) {
+		t.Fatalf("events = %+v", events)
+	}
+	for i := range wantEvents {
+		if events[i] != wantEvents[i] {
+			t.Errorf("event %d = %+v, want %+v", i, events[i], wantEvents[i])
+		}
+	}
+
+	if len(blocked) != 1 {
+		t.Errorf("blocked = %+v, want the write alone", blocked)
+	}
+	// the write corrupts row 2, the watcher itself doesn't
+	corruptOAM(want[:], 2, oamWrite)
+	if inner.oam != want {
+		t.Errorf("OAM row 2 = % X, want % X", inner.oam[0x10:0x18], want[0x10:0x18])
+	}
+}
@@ -0,0 +1,80 @@
+package gameboy
+
+import "time"
+
+// FrameRate is the refresh rate of the DMG LCD, ~59.73 Hz.
+const FrameRate = float64(ClockSpeed) / float64(CyclesPerFrame)
+
+// Unlimited disables pacing when used as a speed multiplier.
+const Unlimited = 0
+
+// MinSpeed is the slowest supported speed multiplier.
+const MinSpeed = 0.25
+
+// if we fall behind more than this, catch up is dropped instead of running
+// frames back to back
+const maxLag = 100 * time.Millisecond
+
+// Pacer keeps the emulation in real time by sleeping between frames.
+type Pacer struct {
+	speed    float64
+	turbo    bool
+	deadline time.Time
+}
+
+// NewPacer returns a pacer running at speed times the real hardware, use
+// Unlimited to run as fast as possible.
+func NewPacer(speed float64) *Pacer {
+	m := &Pacer{}
+	m.SetSpeed(speed)
+	return m
+}
+
+func (m *Pacer) Speed() float64 {
+	return m.speed
+}
+
+// SetSpeed changes the speed multiplier, values under MinSpeed other than
+// Unlimited are clamped.
+func (m *Pacer) SetSpeed(speed float64) {
+	if speed != Unlimited && speed < MinSpeed {
+		speed = MinSpeed
+	}
+
+	m.speed = speed
+	m.deadline = time.Time{}
+}
+
+func (m *Pacer) Turbo() bool {
+	return m.turbo
+}
+
+// SetTurbo runs unlimited while enabled without losing the configured speed.
+func (m *Pacer) SetTurbo(enabled bool) {
+	m.turbo = enabled
+	m.deadline = time.Time{}
+}
+
+func (m *Pacer) ToggleTurbo() {
+	m.SetTurbo(!m.turbo)
+}
+
+// Wait blocks until it is time to run the next frame.
+func (m *Pacer) Wait() {
+	if m.turbo || m.speed == Unlimited {
+		return
+	}
+
+	now := time.Now()
+	frame := time.Duration(float64(time.Second) / (FrameRate * m.speed))
+
+	if m.deadline.IsZero() || now.Sub(m.deadline) > maxLag {
+		m.deadline = now
+	}
+
+	m.deadline = m.deadline.Add(frame)
+
+	if wait := m.deadline.Sub(now); wait > 0 {
+		time.Sleep(wait)
+	}
+}
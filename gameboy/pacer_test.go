@@ -0,0 +1,65 @@
+package gameboy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPacerSetSpeed(t *testing.T) {
+	tests := []struct {
+		speed float64
+		want  float64
+	}{
+		{1, 1},
+		{2.5, 2.5},
+		{MinSpeed, MinSpeed},
+		{0.1, MinSpeed},
+		{-1, MinSpeed},
+		{Unlimited, Unlimited},
+	}
+
+	for _, test := range tests {
+		m := NewPacer(1)
+		m.SetSpeed(test.speed)
+		if got := m.Speed(); got != test.want {
+			t.Errorf("SetSpeed(%v) set %v, want %v", test.speed, got, test.want)
+		}
+	}
+}
+
+// waitFrames returns how long n calls of Wait take.
+func waitFrames(m *Pacer, n int) time.Duration {
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		m.Wait()
+	}
+	return time.Since(start)
+}
+
+func TestPacerWait(t *testing.T) {
+	// ~4.2ms a frame
+	speed := 4.0
+	m := NewPacer(speed)
+	frame := time.Duration(float64(time.Second) / (FrameRate * speed))
+	if got := waitFrames(m, 3); got < 3*frame {
+		t.Errorf("3 frames at 4x took %v, want at least %v", got, 3*frame)
+	}
+
+	// nothing sleeps when unlimited, a single frame would take longer than
+	// the whole loop
+	m = NewPacer(Unlimited)
+	if got := waitFrames(m, 1000); got >= frame {
+		t.Errorf("1000 unlimited frames took %v", got)
+	}
+
+	m = NewPacer(MinSpeed)
+	m.ToggleTurbo()
+	if got := waitFrames(m, 1000); !m.Turbo() || got >= frame {
+		t.Errorf("1000 turbo frames took %v", got)
+	}
+
+	m.ToggleTurbo()
+	if m.Turbo() || m.Speed() != MinSpeed {
+		t.Errorf("turbo off left turbo %t and speed %v, want false and %v", m.Turbo(), m.Speed(), MinSpeed)
+	}
+}
@@ -0,0 +1,228 @@
+package gameboy
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/brunocroh/gameboy/gameboy/cpu"
+)
+
+// test ROMs aren't distributed with the repository, they are looked up in
+// roms/ (blargg's cpu_instrs, instr_timing, mem_timing, mooneye acceptance...)
+const testROMsDir = "../roms"
+
+const (
+	// blargg's cpu_instrs takes almost a minute on hardware
+	blarggBudget  = ClockSpeed * 120
+	mooneyeBudget = ClockSpeed * 20
+)
+
+// mooneye signals the result with LD B,B, on success the registers hold the
+// start of the Fibonacci sequence.
+var mooneyePass = [6]byte{3, 5, 8, 13, 21, 34}
+
+type romResult struct {
+	rom    string
+	passed bool
+	detail string
+	cycles uint64
+}
+
+func findTestROMs(t *testing.T) []string {
+	var roms []string
+	filepath.WalkDir(testROMsDir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && (strings.HasSuffix(path, ".gb") || strings.HasSuffix(path, ".gbc")) {
+			roms = append(roms, path)
+		}
+		return nil
+	})
+
+	if len(roms) == 0 {
+		t.Skipf("no test ROMs found in %s", testROMsDir)
+	}
+
+	return roms
+}
+
+func isMooneye(rom string) bool {
+	path := strings.ToLower(filepath.ToSlash(rom))
+	return strings.Contains(path, "mooneye") || strings.Contains(path, "acceptance/")
+}
+
+func TestROMs(t *testing.T) {
+	roms := findTestROMs(t)
+
+	var mu sync.Mutex
+	var results []romResult
+
+	t.Run("group", func(t *testing.T) {
+		for _, rom := range roms {
+			rom := rom
+			name, _ := filepath.Rel(testROMsDir, rom)
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				result := runTestROM(rom)
+				result.rom = name
+
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+
+				if !result.passed {
+					t.Errorf("%s: %s", name, result.detail)
+				}
+			})
+		}
+	})
+
+	var table strings.Builder
+	w := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ROM\tRESULT\tSECONDS\tDETAIL")
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].rom < results[j].rom
+	})
+
+	passed := 0
+	for _, result := range results {
+		status := "FAIL"
+		if result.passed {
+			status = "PASS"
+			passed++
+		}
+		seconds := float64(result.cycles) / ClockSpeed
+		fmt.Fprintf(w, "%s\t%s\t%.1f\t%s\n", result.rom, status, seconds, result.detail)
+	}
+	w.Flush()
+
+	fmt.Fprintf(&table, "%d/%d passed\n", passed, len(results))
+	t.Log("\n" + table.String())
+}
+
+// runTestROM runs a ROM headless until it reports a result or the cycle
+// budget runs out.
+func runTestROM(rom string) romResult {
+	if _, err := os.Stat(rom); err != nil {
+		return romResult{detail: err.Error()}
+	}
+
+	gb := New()
+	gb.SetSkipBoot(true)
+	gb.Init(rom)
+	gb.SetLockupPolicy(LockupStop)
+
+	var output bytes.Buffer
+	gb.SetSerialOutput(&output)
+
+	mooneye := isMooneye(rom)
+	budget := uint64(blarggBudget)
+	if mooneye {
+		budget = mooneyeBudget
+	}
+
+	var breakpoint *cpu.State
+	if mooneye {
+		gb.OnBreakpoint(func(s cpu.State) {
+			breakpoint = &s
+		})
+	}
+
+	for gb.Cycles() < budget {
+		if err := gb.Update(); err != nil {
+			return romResult{detail: err.Error(), cycles: gb.Cycles()}
+		}
+
+		if breakpoint != nil {
+			return mooneyeResult(*breakpoint, gb.Cycles())
+		}
+
+		// nothing presses a key, the clock would never move again
+		if gb.Stopped() {
+			return romResult{detail: "stopped waiting for a joypad key", cycles: gb.Cycles()}
+		}
+
+		if !mooneye {
+			if result, done := blarggResult(gb, output.String()); done {
+				return result
+			}
+		}
+	}
+
+	detail := "timed out"
+	if output.Len() > 0 {
+		detail += ": " + lastLine(output.String())
+	}
+	return romResult{detail: detail, cycles: gb.Cycles()}
+}
+
+func mooneyeResult(s cpu.State, cycles uint64) romResult {
+	registers := [6]byte{
+		byte(s.BC >> 8), byte(s.BC),
+		byte(s.DE >> 8), byte(s.DE),
+		byte(s.HL >> 8), byte(s.HL),
+	}
+
+	if registers == mooneyePass {
+		return romResult{passed: true, cycles: cycles}
+	}
+
+	return romResult{
+		detail: fmt.Sprintf("B:%02X C:%02X D:%02X E:%02X H:%02X L:%02X", registers[0], registers[1],
+			registers[2], registers[3], registers[4], registers[5]),
+		cycles: cycles,
+	}
+}
+
+// blarggResult looks for the result in the serial output, or in the
+// cartridge RAM for the ROMs that only report it there: A001-A003 hold the
+// DE B0 61 signature, A000 the result code once it isn't 80 (running) and
+// the text starts at A004.
+func blarggResult(gb *GameBoy, output string) (romResult, bool) {
+	switch {
+	case completedLine(output, "Passed"):
+		return romResult{passed: true, cycles: gb.Cycles()}, true
+	case completedLine(output, "Failed"):
+		return romResult{detail: strings.Join(strings.Fields(output), " "), cycles: gb.Cycles()}, true
+	}
+
+	if gb.Peek(0xA001) != 0xDE || gb.Peek(0xA002) != 0xB0 || gb.Peek(0xA003) != 0x61 {
+		return romResult{}, false
+	}
+
+	code := gb.Peek(0xA000)
+	if code == 0x80 {
+		return romResult{}, false
+	}
+
+	var text strings.Builder
+	for address := uint16(0xA004); address < 0xC000; address++ {
+		c := gb.Peek(address)
+		if c == 0 {
+			break
+		}
+		text.WriteByte(c)
+	}
+
+	return romResult{passed: code == 0, detail: lastLine(text.String()), cycles: gb.Cycles()}, true
+}
+
+// completedLine reports if word was printed and its line is complete.
+func completedLine(output, word string) bool {
+	i := strings.Index(output, word)
+	return i >= 0 && strings.Contains(output[i:], "\n")
+}
+
+func lastLine(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
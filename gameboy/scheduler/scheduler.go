@@ -0,0 +1,126 @@
+package scheduler
+
+// EventType identifies a component event. Each type can be pending at most
+// once, scheduling it again moves the existing entry.
+type EventType int
+
+const (
+	TimerOverflow EventType = iota
+	PPUMode
+	SerialBit
+	OAMDMA
+
+	eventTypeCount
+)
+
+type event struct {
+	kind EventType
+	at   uint64
+}
+
+// Scheduler keeps the master clock of the system, counted in T-cycles, and a
+// timestamp ordered queue of upcoming component events.
+//
+// Components don't tick every cycle, they catch up lazily from Now() when one
+// of their registers is touched and schedule an event for the next moment
+// they need to act on their own (an interrupt, a mode change...).
+type Scheduler struct {
+	now      uint64
+	queue    []event
+	handlers [eventTypeCount]func(late uint64)
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+func (m *Scheduler) Init() {
+	m.now = 0
+	m.queue = m.queue[:0]
+}
+
+// Now returns the current timestamp in T-cycles.
+func (m *Scheduler) Now() uint64 {
+	return m.now
+}
+
+// Handle registers the callback fired when an event of the given type is due.
+// late is how many cycles after the scheduled timestamp the event was fired.
+func (m *Scheduler) Handle(kind EventType, handler func(late uint64)) {
+	m.handlers[kind] = handler
+}
+
+// Schedule (re)schedules an event to fire in the given amount of T-cycles.
+func (m *Scheduler) Schedule(kind EventType, in uint64) {
+	m.ScheduleAt(kind, m.now+in)
+}
+
+// ScheduleAt (re)schedules an event to fire at an absolute timestamp.
+func (m *Scheduler) ScheduleAt(kind EventType, at uint64) {
+	m.Cancel(kind)
+
+	i := len(m.queue)
+	for i > 0 && m.queue[i-1].at > at {
+		i--
+	}
+
+	m.queue = append(m.queue, event{})
+	copy(m.queue[i+1:], m.queue[i:])
+	m.queue[i] = event{kind: kind, at: at}
+}
+
+// Cancel removes a pending event, it is a no-op if it isn't scheduled.
+func (m *Scheduler) Cancel(kind EventType) {
+	for i, e := range m.queue {
+		if e.kind == kind {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// Pending reports if an event is scheduled and when it will fire.
+func (m *Scheduler) Pending(kind EventType) (uint64, bool) {
+	for _, e := range m.queue {
+		if e.kind == kind {
+			return e.at, true
+		}
+	}
+	return 0, false
+}
+
+// UntilNext returns how many T-cycles are left until the next event, false
+// is returned when nothing is scheduled.
+func (m *Scheduler) UntilNext() (uint64, bool) {
+	if len(m.queue) == 0 {
+		return 0, false
+	}
+
+	if m.queue[0].at <= m.now {
+		return 0, true
+	}
+
+	return m.queue[0].at - m.now, true
+}
+
+// Advance moves the clock forward, firing every event that becomes due in
+// timestamp order. The clock is set to the event timestamp while its handler
+// runs, so components catching up from it see the exact moment it happened.
+func (m *Scheduler) Advance(cycles uint64) {
+	target := m.now + cycles
+
+	for len(m.queue) > 0 && m.queue[0].at <= target {
+		e := m.queue[0]
+		m.queue = append(m.queue[:0], m.queue[1:]...)
+
+		if e.at > m.now {
+			m.now = e.at
+		}
+
+		if handler := m.handlers[e.kind]; handler != nil {
+			handler(m.now - e.at)
+		}
+	}
+
+	m.now = target
+}
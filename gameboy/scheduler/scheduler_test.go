@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fired struct {
+	kind EventType
+	at   uint64
+	late uint64
+}
+
+// record registers a handler on every event type, appending what fired and
+// the clock seen by the handler.
+func record(s *Scheduler) *[]fired {
+	log := &[]fired{}
+	for kind := EventType(0); kind < eventTypeCount; kind++ {
+		s.Handle(kind, func(late uint64) {
+			*log = append(*log, fired{kind, s.Now(), late})
+		})
+	}
+	return log
+}
+
+func TestScheduleOrder(t *testing.T) {
+	s := New()
+	log := record(s)
+
+	s.ScheduleAt(PPUMode, 30)
+	s.Schedule(TimerOverflow, 10)
+	s.ScheduleAt(OAMDMA, 20)
+	// same timestamp as TimerOverflow, fires after it
+	s.ScheduleAt(SerialBit, 10)
+
+	s.Advance(100)
+
+	want := []fired{
+		{TimerOverflow, 10, 0},
+		{SerialBit, 10, 0},
+		{OAMDMA, 20, 0},
+		{PPUMode, 30, 0},
+	}
+	if !reflect.DeepEqual(*log, want) {
+		t.Errorf("fired %v, want %v", *log, want)
+	}
+	if s.Now() != 100 {
+		t.Errorf("Now() = %d after the advance, want 100", s.Now())
+	}
+}
+
+func TestScheduleMoves(t *testing.T) {
+	s := New()
+	log := record(s)
+
+	s.ScheduleAt(TimerOverflow, 10)
+	s.ScheduleAt(SerialBit, 5)
+	// scheduling again moves the event after the other one with its timestamp
+	s.ScheduleAt(TimerOverflow, 5)
+
+	s.Advance(20)
+
+	want := []fired{
+		{SerialBit, 5, 0},
+		{TimerOverflow, 5, 0},
+	}
+	if !reflect.DeepEqual(*log, want) {
+		t.Errorf("fired %v, want %v", *log, want)
+	}
+}
+
+func TestScheduleLate(t *testing.T) {
+	s := New()
+	log := record(s)
+
+	s.Advance(10)
+	s.ScheduleAt(PPUMode, 4)
+	if until, ok := s.UntilNext(); !ok || until != 0 {
+		t.Errorf("UntilNext() = %d, %t for an overdue event, want 0, true", until, ok)
+	}
+
+	s.Advance(0)
+
+	want := []fired{{PPUMode, 10, 6}}
+	if !reflect.DeepEqual(*log, want) {
+		t.Errorf("fired %v, want %v", *log, want)
+	}
+}
+
+func TestCancelPending(t *testing.T) {
+	s := New()
+	log := record(s)
+
+	if _, ok := s.UntilNext(); ok {
+		t.Error("UntilNext() reported an event with nothing scheduled")
+	}
+
+	s.Advance(3)
+	s.Schedule(TimerOverflow, 20)
+	s.Schedule(SerialBit, 8)
+
+	if at, ok := s.Pending(TimerOverflow); !ok || at != 23 {
+		t.Errorf("Pending(TimerOverflow) = %d, %t, want 23, true", at, ok)
+	}
+	if until, ok := s.UntilNext(); !ok || until != 8 {
+		t.Errorf("UntilNext() = %d, %t, want 8, true", until, ok)
+	}
+
+	s.Cancel(SerialBit)
+	// cancelling what isn't scheduled does nothing
+	s.Cancel(SerialBit)
+	s.Cancel(OAMDMA)
+
+	if _, ok := s.Pending(SerialBit); ok {
+		t.Error("SerialBit still pending after Cancel")
+	}
+	if until, ok := s.UntilNext(); !ok || until != 20 {
+		t.Errorf("UntilNext() = %d, %t after Cancel, want 20, true", until, ok)
+	}
+
+	s.Advance(30)
+
+	want := []fired{{TimerOverflow, 23, 0}}
+	if !reflect.DeepEqual(*log, want) {
+		t.Errorf("fired %v, want %v", *log, want)
+	}
+	if _, ok := s.Pending(TimerOverflow); ok {
+		t.Error("TimerOverflow still pending after firing")
+	}
+}
+
+func TestAdvanceReschedule(t *testing.T) {
+	s := New()
+	var log []fired
+
+	// a periodic event scheduling itself again from its handler
+	s.Handle(TimerOverflow, func(late uint64) {
+		log = append(log, fired{TimerOverflow, s.Now(), late})
+		s.Schedule(TimerOverflow, 4)
+	})
+	s.Handle(PPUMode, func(late uint64) {
+		log = append(log, fired{PPUMode, s.Now(), late})
+	})
+
+	s.Schedule(TimerOverflow, 4)
+	s.Schedule(PPUMode, 6)
+
+	s.Advance(10)
+
+	want := []fired{
+		{TimerOverflow, 4, 0},
+		{PPUMode, 6, 0},
+		{TimerOverflow, 8, 0},
+	}
+	if !reflect.DeepEqual(log, want) {
+		t.Errorf("fired %v, want %v", log, want)
+	}
+	if at, ok := s.Pending(TimerOverflow); !ok || at != 12 {
+		t.Errorf("Pending(TimerOverflow) = %d, %t, want 12, true", at, ok)
+	}
+
+	// an event due exactly at the target fires within the same advance
+	s.Advance(2)
+	if len(log) != 4 || log[3] != (fired{TimerOverflow, 12, 0}) {
+		t.Errorf("fired %v, want TimerOverflow at 12 last", log)
+	}
+}